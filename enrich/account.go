@@ -0,0 +1,321 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "sort"
+  "strings"
+  "sync"
+)
+
+
+const (
+  defaultAccountMaxContacts = 5
+  defaultAccountConcurrency = 4
+)
+
+
+// AccountService service
+type AccountService service
+
+
+// AccountOptions mapping
+type AccountOptions struct {
+  MaxContacts          int
+  MinSeniority         Seniority
+  Roles                []Role
+  EnrichContacts       bool
+  ValidateEmails       bool
+  EnrichConcurrency    int
+  ValidateConcurrency  int
+  Budget               int
+}
+
+// Account mapping
+type Account struct {
+  Domain    string            `json:"domain"`
+  Company   *Company          `json:"company,omitempty"`
+  Contacts  []AccountContact  `json:"contacts"`
+  Calls     int               `json:"calls"`
+  Partial   bool              `json:"partial"`
+}
+
+// AccountContact mapping
+type AccountContact struct {
+  Person      *Person             `json:"person"`
+  Email       string              `json:"email,omitempty"`
+  Validation  *ValidateEmailData  `json:"validation,omitempty"`
+  Err         error               `json:"-"`
+}
+
+// accountBudget counts API calls spent on an account expansion
+type accountBudget struct {
+  lock       sync.Mutex
+  limit      int
+  spent      int
+  exhausted  bool
+}
+
+// accountContactPolicy merges enriched persons into key contacts, preferring enriched values
+var accountContactPolicy = &MergePolicy{Default: MergeUnion}
+
+
+// String returns the string representation of Account
+func (instance Account) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of AccountContact
+func (instance AccountContact) String() string {
+  return Stringify(instance)
+}
+
+
+// EnrichAccount returns a consolidated account view for a company domain, using default options.
+func (service *AccountService) EnrichAccount(domain string) (*Account, error) {
+  return service.EnrichAccountWithOptions(domain, AccountOptions{})
+}
+
+
+// EnrichAccountWithOptions returns a consolidated account view for a company domain.
+//
+// The company is enriched, then its most senior employees are picked as key contacts (preferring employees with an email when contacts get enriched or validated, as both stages need one). Contacts are then optionally enriched and their emails validated, with each stage running concurrently and all stages sharing the call budget (the company lookup is not counted).
+func (service *AccountService) EnrichAccountWithOptions(domain string, options AccountOptions) (*Account, error) {
+  if options.MaxContacts <= 0 {
+    options.MaxContacts = defaultAccountMaxContacts
+  }
+  if options.EnrichConcurrency <= 0 {
+    options.EnrichConcurrency = defaultAccountConcurrency
+  }
+  if options.ValidateConcurrency <= 0 {
+    options.ValidateConcurrency = defaultAccountConcurrency
+  }
+
+  data, _, err := service.client.Enrich.EnrichCompanyBy("domain", domain)
+  if err != nil {
+    return nil, err
+  }
+
+  account := &Account{Domain: domain, Company: data.Company}
+  budget := &accountBudget{limit: options.Budget}
+
+  for _, employee := range accountKeyEmployees(data.Company, options) {
+    account.Contacts = append(account.Contacts, newAccountContact(employee, data.Company, domain))
+  }
+
+  if options.EnrichContacts == true {
+    runAccountStage(len(account.Contacts), options.EnrichConcurrency, func(index int) {
+      service.enrichContact(&account.Contacts[index], budget)
+    })
+  }
+
+  if options.ValidateEmails == true {
+    runAccountStage(len(account.Contacts), options.ValidateConcurrency, func(index int) {
+      service.validateContact(&account.Contacts[index], budget)
+    })
+  }
+
+  account.Calls = budget.spent
+  account.Partial = budget.exhausted
+
+  return account, nil
+}
+
+
+// enrichContact enriches a key contact by email, merging the enriched person into it
+func (service *AccountService) enrichContact(contact *AccountContact, budget *accountBudget) {
+  if contact.Email == "" || budget.spend() == false {
+    return
+  }
+
+  data, _, err := service.client.Enrich.EnrichPersonBy("email", contact.Email)
+  if err != nil {
+    // Unknown persons are expected, and leave the contact as-is
+    if responseErr, ok := err.(*ResponseError); ok == false || responseErr.Reason != "not_found" {
+      contact.Err = err
+    }
+
+    return
+  }
+
+  if data.Person != nil {
+    merged := &Person{}
+
+    if _, err = accountContactPolicy.Merge(merged, MergeSource{Lookup: "email", Data: data.Person}, MergeSource{Lookup: "employee", Data: contact.Person}); err != nil {
+      contact.Err = err
+
+      return
+    }
+
+    contact.Person = merged
+
+    // The enriched primary email is validated over the employee one, which may be stale
+    if email := accountPersonEmail(data.Person.Contact); email != "" {
+      contact.Email = email
+    }
+  }
+}
+
+
+// validateContact validates the email of a key contact
+func (service *AccountService) validateContact(contact *AccountContact, budget *accountBudget) {
+  if contact.Email == "" || budget.spend() == false {
+    return
+  }
+
+  validation, _, err := service.client.Verify.ValidateEmail(contact.Email)
+  if err != nil {
+    if contact.Err == nil {
+      contact.Err = err
+    }
+
+    return
+  }
+
+  contact.Validation = validation
+}
+
+
+// spend spends an API call from the budget, if any left
+func (budget *accountBudget) spend() bool {
+  budget.lock.Lock()
+  defer budget.lock.Unlock()
+
+  if budget.limit > 0 && budget.spent >= budget.limit {
+    budget.exhausted = true
+
+    return false
+  }
+
+  budget.spent++
+
+  return true
+}
+
+
+// accountKeyEmployees picks the key employees of a company, most senior first
+func accountKeyEmployees(company *Company, options AccountOptions) []CompanyEmployeesPerson {
+  var employees []CompanyEmployeesPerson
+
+  if company == nil || company.Employees == nil || company.Employees.Persons == nil {
+    return nil
+  }
+
+  for _, employee := range *company.Employees.Persons {
+    var seniority Seniority
+    var role Role
+
+    if employee.Employment != nil {
+      if employee.Employment.Seniority != nil {
+        seniority = *employee.Employment.Seniority
+      }
+      if employee.Employment.Role != nil {
+        role = *employee.Employment.Role
+      }
+    }
+
+    if options.MinSeniority != "" && seniority.AtLeast(options.MinSeniority) == false {
+      continue
+    }
+    if len(options.Roles) > 0 && containsRole(options.Roles, role) == false {
+      continue
+    }
+
+    employees = append(employees, employee)
+  }
+
+  // Employees without an email would be skipped by both stages, thus only fill remaining slots
+  preferEmail := options.EnrichContacts == true || options.ValidateEmails == true
+
+  sort.SliceStable(employees, func(i, j int) bool {
+    if preferEmail == true {
+      if first, second := accountPersonEmail(employees[i].Contact) != "", accountPersonEmail(employees[j].Contact) != ""; first != second {
+        return first
+      }
+    }
+
+    return accountSeniorityRank(employees[i]) > accountSeniorityRank(employees[j])
+  })
+
+  if len(employees) > options.MaxContacts {
+    employees = employees[:options.MaxContacts]
+  }
+
+  return employees
+}
+
+
+// newAccountContact returns a key contact for an employee, with its employment at the company
+func newAccountContact(employee CompanyEmployeesPerson, company *Company, domain string) AccountContact {
+  person := &Person{ID: employee.ID, Name: employee.Name, Contact: employee.Contact}
+
+  employment := PersonEmployment{ID: company.ID, Name: company.Name, Domain: &domain}
+
+  if employee.Employment != nil {
+    employment.Title = employee.Employment.Title
+    employment.Role = employee.Employment.Role
+    employment.Seniority = employee.Employment.Seniority
+  }
+
+  person.Employments = &[]PersonEmployment{employment}
+
+  return AccountContact{Person: person, Email: accountPersonEmail(employee.Contact)}
+}
+
+
+// accountPersonEmail returns the primary email of a contact, if any
+func accountPersonEmail(contact *Contact) string {
+  if contact == nil || contact.Emails == nil || len(*contact.Emails) == 0 {
+    return ""
+  }
+
+  return strings.TrimSpace((*contact.Emails)[0])
+}
+
+
+// runAccountStage runs a stage over items, with at most a given number of items processed concurrently
+func runAccountStage(count int, concurrency int, stage func(index int)) {
+  var waitGroup sync.WaitGroup
+
+  slots := make(chan struct{}, concurrency)
+
+  for i := 0; i < count; i++ {
+    waitGroup.Add(1)
+    slots <- struct{}{}
+
+    go func(index int) {
+      defer waitGroup.Done()
+      defer func() { <-slots }()
+
+      stage(index)
+    }(i)
+  }
+
+  waitGroup.Wait()
+}
+
+
+// accountSeniorityRank returns the seniority rank of an employee (0 if unknown)
+func accountSeniorityRank(employee CompanyEmployeesPerson) int {
+  if employee.Employment == nil || employee.Employment.Seniority == nil {
+    return 0
+  }
+
+  return employee.Employment.Seniority.Rank()
+}
+
+
+// containsRole returns whether a list of roles contains a role
+func containsRole(roles []Role, role Role) bool {
+  for _, current := range roles {
+    if equalEnum(string(current), string(role)) == true {
+      return true
+    }
+  }
+
+  return false
+}
@@ -0,0 +1,329 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "fmt"
+  "net"
+  "strings"
+  "sync"
+  "time"
+)
+
+
+const (
+  defaultAttributionCacheTTL = 24 * time.Hour
+  defaultAttributionCacheSize = 100000
+)
+
+
+// AttributionTraffic maps the kind of traffic an IP was classified as
+type AttributionTraffic string
+
+// AttributionConfidence maps the confidence level of an attribution
+type AttributionConfidence string
+
+// Attribution traffic kinds
+const (
+  AttributionBusiness AttributionTraffic = "business"
+  AttributionResidential AttributionTraffic = "residential"
+  AttributionMobile AttributionTraffic = "mobile"
+  AttributionVPN AttributionTraffic = "vpn"
+  AttributionHosting AttributionTraffic = "hosting"
+  AttributionReserved AttributionTraffic = "reserved"
+  AttributionUnknown AttributionTraffic = "unknown"
+)
+
+// Attribution confidence levels
+const (
+  AttributionConfidenceHigh AttributionConfidence = "high"
+  AttributionConfidenceMedium AttributionConfidence = "medium"
+  AttributionConfidenceLow AttributionConfidence = "low"
+  AttributionConfidenceNone AttributionConfidence = "none"
+)
+
+// attributionBogons lists private, reserved and bogon ranges, which are never looked up
+var attributionBogons = parseAttributionBlocks(
+  "0.0.0.0/8",
+  "10.0.0.0/8",
+  "100.64.0.0/10",
+  "127.0.0.0/8",
+  "169.254.0.0/16",
+  "172.16.0.0/12",
+  "192.0.0.0/24",
+  "192.0.2.0/24",
+  "192.88.99.0/24",
+  "192.168.0.0/16",
+  "198.18.0.0/15",
+  "198.51.100.0/24",
+  "203.0.113.0/24",
+  "224.0.0.0/4",
+  "240.0.0.0/4",
+  "100::/64",
+  "2001:db8::/32",
+  "2001:10::/28",
+  "2002::/16",
+)
+
+// attributionGlobalUnicast is the only IPv6 range allocated for public use
+var attributionGlobalUnicast = parseAttributionBlocks("2000::/3")[0]
+
+
+// Attribution maps the company an IP is attributed to
+type Attribution struct {
+  IP          string                 `json:"ip"`
+  Block       string                 `json:"block,omitempty"`
+  Traffic     AttributionTraffic     `json:"traffic"`
+  Company     *Company               `json:"company,omitempty"`
+  Confidence  AttributionConfidence  `json:"confidence"`
+  Cached      bool                   `json:"cached"`
+}
+
+// AttributorStats maps the counters of an attributor
+type AttributorStats struct {
+  Lookups  int  `json:"lookups"`
+  Hits     int  `json:"hits"`
+  Skipped  int  `json:"skipped"`
+  Entries  int  `json:"entries"`
+}
+
+// Attributor attributes visitor IPs to companies, caching results per network block
+//
+// IPs are collapsed to the network block returned by the API, so that any other IP within a known block is attributed without a lookup. Residential, mobile, VPN and hosting traffic is classified out, and never attributed to a company. An attributor is safe for concurrent use.
+type Attributor struct {
+  CacheTTL   time.Duration
+  CacheSize  int
+
+  client  *Client
+  cache   *blockCache
+
+  lock   sync.Mutex
+  stats  AttributorStats
+}
+
+
+// String returns the string representation of Attribution
+func (instance Attribution) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of AttributorStats
+func (instance AttributorStats) String() string {
+  return Stringify(instance)
+}
+
+
+// NewAttributor returns a new attributor, with default cache settings
+func NewAttributor(client *Client) *Attributor {
+  return &Attributor{
+    CacheTTL: defaultAttributionCacheTTL,
+    CacheSize: defaultAttributionCacheSize,
+    client: client,
+    cache: newBlockCache(),
+  }
+}
+
+
+// Attribute attributes an IP to a company
+//
+// Private, reserved and bogon IPs are classified as reserved without any lookup. IPs unknown to the API are attributed to no company, and cached as well.
+func (attributor *Attributor) Attribute(value string) (*Attribution, error) {
+  ip := net.ParseIP(strings.TrimSpace(value))
+
+  if ip == nil {
+    return nil, fmt.Errorf("invalid IP: %q", value)
+  }
+
+  if ip4 := ip.To4(); ip4 != nil {
+    ip = ip4
+  }
+
+  if isAttributionBogon(ip) == true {
+    attributor.lock.Lock()
+    attributor.stats.Skipped++
+    attributor.lock.Unlock()
+
+    return &Attribution{IP: ip.String(), Traffic: AttributionReserved, Confidence: AttributionConfidenceNone}, nil
+  }
+
+  if attribution := attributor.cached(ip); attribution != nil {
+    return attribution, nil
+  }
+
+  data, _, err := attributor.client.Enrich.EnrichNetworkBy("ip", ip.String())
+  if err != nil {
+    if responseErr, ok := err.(*ResponseError); ok == false || responseErr.Reason != "not_found" {
+      return nil, err
+    }
+
+    data = &EnrichNetworkData{}
+  }
+
+  attribution := newAttribution(data)
+  block := attributionBlock(ip, data.Network)
+
+  attribution.IP = ip.String()
+  attribution.Block = block.String()
+
+  attributor.cache.put(block, *attribution, attributor.CacheTTL, attributor.CacheSize)
+
+  return attribution, nil
+}
+
+
+// Stats returns the counters of the attributor
+func (attributor *Attributor) Stats() AttributorStats {
+  attributor.lock.Lock()
+  defer attributor.lock.Unlock()
+
+  stats := attributor.stats
+  stats.Entries = attributor.cache.len()
+
+  return stats
+}
+
+
+// Purge removes all cached blocks
+func (attributor *Attributor) Purge() {
+  attributor.cache.purge()
+}
+
+
+// cached returns the cached attribution of an IP, from the most specific block containing it
+func (attributor *Attributor) cached(ip net.IP) *Attribution {
+  value, ok := attributor.cache.get(ip)
+
+  attributor.lock.Lock()
+  defer attributor.lock.Unlock()
+
+  attributor.stats.Lookups++
+
+  if ok == false {
+    return nil
+  }
+
+  attributor.stats.Hits++
+
+  attribution := value.(Attribution)
+  attribution.IP = ip.String()
+  attribution.Cached = true
+
+  return &attribution
+}
+
+
+// newAttribution classifies a network enrichment, and attributes it to a company
+func newAttribution(data *EnrichNetworkData) *Attribution {
+  attribution := &Attribution{Traffic: attributionTraffic(data), Confidence: AttributionConfidenceNone}
+
+  if attribution.Traffic != AttributionBusiness && attribution.Traffic != AttributionUnknown {
+    return attribution
+  }
+
+  office := data.Network != nil && data.Network.Usage != nil && data.Network.Usage.Office != nil && *data.Network.Usage.Office == true
+
+  switch {
+    case data.Company != nil && office == true:
+      attribution.Company, attribution.Confidence = data.Company, AttributionConfidenceHigh
+
+    case data.Company != nil:
+      attribution.Company, attribution.Confidence = data.Company, AttributionConfidenceMedium
+
+    case data.Network != nil && data.Network.Block != nil && data.Network.Block.Owner != nil && strings.TrimSpace(stringValue(data.Network.Block.Owner.Organization)) != "":
+      // The block owner may be an ISP leasing the block, thus is a weak signal
+      attribution.Company = &Company{Name: data.Network.Block.Owner.Organization, Contact: data.Network.Block.Owner.Contact, Address: data.Network.Block.Owner.Address}
+      attribution.Confidence = AttributionConfidenceLow
+  }
+
+  if attribution.Company != nil {
+    attribution.Traffic = AttributionBusiness
+  }
+
+  return attribution
+}
+
+
+// attributionTraffic classifies the traffic of a network from its usage
+func attributionTraffic(data *EnrichNetworkData) AttributionTraffic {
+  if data.Network == nil || data.Network.Usage == nil {
+    return AttributionUnknown
+  }
+
+  usage := data.Network.Usage
+
+  switch {
+    case isAttributionFlag(usage.VPN) == true || isAttributionFlag(usage.TOR) == true:
+      return AttributionVPN
+
+    case isAttributionFlag(usage.Mobile) == true:
+      return AttributionMobile
+
+    case isAttributionFlag(usage.Server) == true:
+      return AttributionHosting
+
+    case isAttributionFlag(usage.Home) == true:
+      return AttributionResidential
+
+    case isAttributionFlag(usage.Office) == true:
+      return AttributionBusiness
+  }
+
+  return AttributionUnknown
+}
+
+
+// attributionBlock returns the network block of an IP, or the IP itself if its block is unknown
+func attributionBlock(ip net.IP, network *Network) *net.IPNet {
+  if network != nil && network.Block != nil && network.Block.Range != nil {
+    if _, block, err := net.ParseCIDR(strings.TrimSpace(*network.Block.Range)); err == nil && block.Contains(ip) == true {
+      return block
+    }
+  }
+
+  bits := len(ip) * 8
+
+  return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
+
+
+// isAttributionBogon returns whether an IP is private, reserved or a bogon
+func isAttributionBogon(ip net.IP) bool {
+  if ip.To4() == nil && attributionGlobalUnicast.Contains(ip) == false {
+    return true
+  }
+
+  for _, block := range attributionBogons {
+    if block.Contains(ip) == true {
+      return true
+    }
+  }
+
+  return false
+}
+
+
+// parseAttributionBlocks parses a list of CIDR blocks
+func parseAttributionBlocks(values ...string) []*net.IPNet {
+  blocks := make([]*net.IPNet, len(values))
+
+  for i, value := range values {
+    _, block, err := net.ParseCIDR(value)
+    if err != nil {
+      panic(err)
+    }
+
+    blocks[i] = block
+  }
+
+  return blocks
+}
+
+
+// isAttributionFlag returns whether a usage flag is set
+func isAttributionFlag(value *bool) bool {
+  return value != nil && *value == true
+}
@@ -0,0 +1,192 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "container/list"
+  "net"
+  "sort"
+  "sync"
+  "time"
+)
+
+
+// blockCache caches values per network block (CIDR), evicting the least recently used blocks when full
+//
+// An IP is looked up in the most specific cached block containing it, by masking it with each cached block size.
+type blockCache struct {
+  lock     sync.Mutex
+  entries  map[string]*list.Element
+  order    *list.List
+  lengths  map[int]int
+  sizes    []int
+}
+
+// blockCacheEntry maps a cached value for a network block
+type blockCacheEntry struct {
+  block    *net.IPNet
+  value    interface{}
+  expires  time.Time
+}
+
+
+// newBlockCache returns an empty block cache
+func newBlockCache() *blockCache {
+  return &blockCache{
+    entries: make(map[string]*list.Element),
+    order: list.New(),
+    lengths: make(map[int]int),
+  }
+}
+
+
+// get returns the value cached for the most specific block containing an IP, if any
+func (cache *blockCache) get(ip net.IP) (interface{}, bool) {
+  cache.lock.Lock()
+  defer cache.lock.Unlock()
+
+  if ip4 := ip.To4(); ip4 != nil {
+    ip = ip4
+  }
+
+  bits := len(ip) * 8
+
+  for _, size := range cache.sizes {
+    // Cached blocks of the other IP family never contain the IP
+    if (size > 32) != (bits == 128) {
+      continue
+    }
+
+    mask := net.CIDRMask(blockCacheMaskSize(size, bits), bits)
+    key := (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String()
+
+    element, ok := cache.entries[key]
+    if ok == false {
+      continue
+    }
+
+    entry := element.Value.(*blockCacheEntry)
+
+    if time.Now().After(entry.expires) == true {
+      cache.remove(element)
+
+      continue
+    }
+
+    cache.order.MoveToFront(element)
+
+    return entry.value, true
+  }
+
+  return nil, false
+}
+
+
+// put caches a value for a block, for a duration and with a maximum number of blocks (zero for unbounded)
+func (cache *blockCache) put(block *net.IPNet, value interface{}, ttl time.Duration, capacity int) {
+  cache.lock.Lock()
+  defer cache.lock.Unlock()
+
+  key := block.String()
+
+  if element, ok := cache.entries[key]; ok == true {
+    cache.remove(element)
+  }
+
+  entry := &blockCacheEntry{block: block, value: value, expires: time.Now().Add(ttl)}
+
+  cache.entries[key] = cache.order.PushFront(entry)
+
+  cache.addSize(blockCacheSize(block))
+
+  for capacity > 0 && cache.order.Len() > capacity {
+    cache.remove(cache.order.Back())
+  }
+}
+
+
+// len returns the number of cached blocks
+func (cache *blockCache) len() int {
+  cache.lock.Lock()
+  defer cache.lock.Unlock()
+
+  return cache.order.Len()
+}
+
+
+// purge removes all cached blocks
+func (cache *blockCache) purge() {
+  cache.lock.Lock()
+  defer cache.lock.Unlock()
+
+  cache.entries = make(map[string]*list.Element)
+  cache.order.Init()
+  cache.lengths = make(map[int]int)
+  cache.sizes = nil
+}
+
+
+// remove removes a cached block
+func (cache *blockCache) remove(element *list.Element) {
+  entry := element.Value.(*blockCacheEntry)
+
+  cache.order.Remove(element)
+  delete(cache.entries, entry.block.String())
+
+  size := blockCacheSize(entry.block)
+
+  if cache.lengths[size]--; cache.lengths[size] <= 0 {
+    delete(cache.lengths, size)
+
+    cache.sortSizes()
+  }
+}
+
+
+// addSize registers the size of a cached block
+func (cache *blockCache) addSize(size int) {
+  if cache.lengths[size]++; cache.lengths[size] == 1 {
+    cache.sortSizes()
+  }
+}
+
+
+// sortSizes lists the sizes of cached blocks, most specific first
+func (cache *blockCache) sortSizes() {
+  // A new list is allocated, as the current one may be iterated over
+  sizes := make([]int, 0, len(cache.lengths))
+
+  for size := range cache.lengths {
+    sizes = append(sizes, size)
+  }
+
+  sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+
+  cache.sizes = sizes
+}
+
+
+// blockCacheSize returns the size of a cached block, offset for IPv6 so that both families never collide
+func blockCacheSize(block *net.IPNet) int {
+  ones, bits := block.Mask.Size()
+
+  if bits == 128 {
+    return ones + 33
+  }
+
+  return ones
+}
+
+
+// blockCacheMaskSize returns the prefix length of a cached block size
+func blockCacheMaskSize(size int, bits int) int {
+  if bits == 128 {
+    return size - 33
+  }
+
+  return size
+}
@@ -0,0 +1,232 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "encoding/json"
+  "errors"
+  "io/ioutil"
+  "net/http"
+  "os"
+  "sync"
+  "time"
+)
+
+
+const (
+  defaultEnvUsernameVariable = "ENRICH_USER_ID"
+  defaultEnvPasswordVariable = "ENRICH_SECRET_KEY"
+  defaultFileCheckInterval = 5 * time.Second
+  defaultRotatingCooldown = 10 * time.Minute
+  maxCredentialsFailovers = 8
+)
+
+// ErrNoCredentials is returned when a provider has no usable credentials
+var ErrNoCredentials = errors.New("no_credentials No usable credentials are available.")
+
+
+// Credentials maps an API key pair
+type Credentials struct {
+  Username  string  `json:"user_id"`
+  Password  string  `json:"secret_key"`
+}
+
+// CredentialsProvider provides credentials, and is consulted for each request
+type CredentialsProvider interface {
+  Credentials() (*Credentials, error)
+}
+
+// CredentialsFailover is implemented by providers which can switch to other credentials
+type CredentialsFailover interface {
+  // Failover marks credentials as failed, and returns whether other credentials are available
+  Failover(credentials *Credentials, err *ResponseError) bool
+}
+
+// StaticCredentials provides a fixed key pair
+type StaticCredentials struct {
+  Username  string
+  Password  string
+}
+
+// EnvCredentials provides a key pair read from environment variables
+type EnvCredentials struct {
+  UsernameVariable  string
+  PasswordVariable  string
+}
+
+// FileCredentials provides a key pair read from a JSON file, reloaded on change
+type FileCredentials struct {
+  Path           string
+  CheckInterval  time.Duration
+
+  lock         sync.Mutex
+  credentials  *Credentials
+  modTime      time.Time
+  size         int64
+  checkedAt    time.Time
+}
+
+// RotatingCredentials provides key pairs from a pool, in turn, skipping failed ones
+type RotatingCredentials struct {
+  Cooldown  time.Duration
+
+  lock      sync.Mutex
+  pool      []Credentials
+  failedAt  []time.Time
+  next      int
+}
+
+
+// String returns the string representation of Credentials (the password is masked)
+func (instance Credentials) String() string {
+  return Stringify(Credentials{Username: instance.Username, Password: "***"})
+}
+
+
+// NewStaticCredentials returns a provider for a fixed key pair
+func NewStaticCredentials(username string, password string) *StaticCredentials {
+  return &StaticCredentials{Username: username, Password: password}
+}
+
+
+// Credentials returns the fixed key pair
+func (provider *StaticCredentials) Credentials() (*Credentials, error) {
+  return &Credentials{Username: provider.Username, Password: provider.Password}, nil
+}
+
+
+// NewEnvCredentials returns a provider reading 'ENRICH_USER_ID' and 'ENRICH_SECRET_KEY'
+func NewEnvCredentials() *EnvCredentials {
+  return &EnvCredentials{UsernameVariable: defaultEnvUsernameVariable, PasswordVariable: defaultEnvPasswordVariable}
+}
+
+
+// Credentials returns the key pair currently set in the environment
+func (provider *EnvCredentials) Credentials() (*Credentials, error) {
+  username := os.Getenv(provider.UsernameVariable)
+  password := os.Getenv(provider.PasswordVariable)
+
+  if username == "" || password == "" {
+    return nil, ErrNoCredentials
+  }
+
+  return &Credentials{Username: username, Password: password}, nil
+}
+
+
+// NewFileCredentials returns a provider reading a JSON file (eg. '{"user_id":"ui_xxx","secret_key":"sk_xxx"}')
+func NewFileCredentials(path string) *FileCredentials {
+  return &FileCredentials{Path: path, CheckInterval: defaultFileCheckInterval}
+}
+
+
+// Credentials returns the key pair from the file, reloading it if it changed
+func (provider *FileCredentials) Credentials() (*Credentials, error) {
+  provider.lock.Lock()
+  defer provider.lock.Unlock()
+
+  now := time.Now()
+
+  if provider.credentials != nil && now.Sub(provider.checkedAt) < provider.CheckInterval {
+    return provider.credentials, nil
+  }
+
+  provider.checkedAt = now
+
+  info, err := os.Stat(provider.Path)
+  if err != nil {
+    return provider.fallback(err)
+  }
+
+  if provider.credentials != nil && info.ModTime().Equal(provider.modTime) && info.Size() == provider.size {
+    return provider.credentials, nil
+  }
+
+  data, err := ioutil.ReadFile(provider.Path)
+  if err != nil {
+    return provider.fallback(err)
+  }
+
+  credentials := &Credentials{}
+
+  if err = json.Unmarshal(data, credentials); err != nil {
+    return provider.fallback(err)
+  }
+  if credentials.Username == "" || credentials.Password == "" {
+    return provider.fallback(ErrNoCredentials)
+  }
+
+  provider.credentials = credentials
+  provider.modTime = info.ModTime()
+  provider.size = info.Size()
+
+  return credentials, nil
+}
+
+
+// fallback keeps serving the last loaded key pair if the file cannot be reloaded
+func (provider *FileCredentials) fallback(err error) (*Credentials, error) {
+  if provider.credentials != nil {
+    return provider.credentials, nil
+  }
+
+  return nil, err
+}
+
+
+// NewRotatingCredentials returns a provider rotating over a pool of key pairs
+func NewRotatingCredentials(pool ...Credentials) *RotatingCredentials {
+  return &RotatingCredentials{Cooldown: defaultRotatingCooldown, pool: pool, failedAt: make([]time.Time, len(pool))}
+}
+
+
+// Credentials returns the next healthy key pair from the pool
+func (provider *RotatingCredentials) Credentials() (*Credentials, error) {
+  provider.lock.Lock()
+  defer provider.lock.Unlock()
+
+  now := time.Now()
+
+  for i := 0; i < len(provider.pool); i++ {
+    index := (provider.next + i) % len(provider.pool)
+
+    if provider.failedAt[index].IsZero() || now.Sub(provider.failedAt[index]) >= provider.Cooldown {
+      provider.failedAt[index] = time.Time{}
+      provider.next = (index + 1) % len(provider.pool)
+
+      credentials := provider.pool[index]
+
+      return &credentials, nil
+    }
+  }
+
+  return nil, ErrNoCredentials
+}
+
+
+// Failover puts a key pair on cooldown when it is unauthorized or out of quota
+func (provider *RotatingCredentials) Failover(credentials *Credentials, err *ResponseError) bool {
+  if err.StatusCode != http.StatusUnauthorized && err.StatusCode != http.StatusPaymentRequired {
+    return false
+  }
+
+  provider.lock.Lock()
+  defer provider.lock.Unlock()
+
+  now := time.Now()
+  available := false
+
+  for index := range provider.pool {
+    if provider.pool[index] == *credentials {
+      provider.failedAt[index] = now
+    } else if provider.failedAt[index].IsZero() || now.Sub(provider.failedAt[index]) >= provider.Cooldown {
+      available = true
+    }
+  }
+
+  return available
+}
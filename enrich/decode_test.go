@@ -0,0 +1,103 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "bytes"
+  "encoding/json"
+  "errors"
+  "io/ioutil"
+  "net/http"
+  "testing"
+)
+
+
+// FuzzDecodeResponse checks that any response body either decodes, or fails with a typed decoding error
+func FuzzDecodeResponse(f *testing.F) {
+  f.Add(http.StatusOK, "application/json", []byte(`{"person":{"name":{"full":"Valerian Saliou"}}}`))
+  f.Add(http.StatusOK, "application/json; charset=utf-8", []byte(`{"person":{"name":{"full":"Valerian`))
+  f.Add(http.StatusOK, "application/json", []byte(`{"person":{"employments":[{"seniority":`))
+  f.Add(http.StatusOK, "text/html", []byte(`<html><body>Bad Gateway</body></html>`))
+  f.Add(http.StatusOK, "application/json", []byte{})
+  f.Add(http.StatusOK, "", []byte(`{"person":{}}`))
+  f.Add(http.StatusOK, "application/json", []byte(`{"person":{}} trailing`))
+  f.Add(http.StatusOK, "application/json", []byte(`{"person":{}}{"person":{}}`))
+  f.Add(http.StatusCreated, "application/problem+json", []byte(`{"unknown":[1,2,3]}`))
+  f.Add(http.StatusNoContent, "application/json", []byte{})
+  f.Add(http.StatusOK, "application/json", []byte(`{"person":{"gender":42}}`))
+
+  f.Fuzz(func(t *testing.T, status int, contentType string, body []byte) {
+    if status < 200 || status > 299 {
+      status = http.StatusOK
+    }
+
+    response := &http.Response{StatusCode: status, Header: http.Header{}, Body: ioutil.NopCloser(bytes.NewReader(body))}
+
+    if contentType != "" {
+      response.Header.Set("Content-Type", contentType)
+    }
+
+    err := decodeResponse(response, new(EnrichPersonData))
+
+    switch typed := err.(type) {
+      case nil, *ContentTypeError, *EmptyBodyError:
+        return
+
+      case *DecodeError:
+        if typed.Err == nil {
+          t.Fatalf("decode error without cause for body %q", body)
+        }
+        if typed.StatusCode != status {
+          t.Fatalf("decode error with status %d, expected %d", typed.StatusCode, status)
+        }
+
+        if errors.Is(err, errTrailingData) == false && len(bytes.TrimSpace(body)) == 0 {
+          t.Fatalf("empty body reported as %v", err)
+        }
+
+      default:
+        t.Fatalf("untyped error %T (%v) for body %q", err, err, body)
+    }
+  })
+}
+
+
+// FuzzUnmarshalWithExtra checks that decoding with unknown fields never panics, and that decoded models encode back
+func FuzzUnmarshalWithExtra(f *testing.F) {
+  f.Add([]byte(`{"name":{"full":"Valerian Saliou"},"unknown":{"nested":[1,2]}}`))
+  f.Add([]byte(`{"name":{"full":"Valerian`))
+  f.Add([]byte(`{"employments":[{"id":"c1","seniority":"executive","unknown":true}]}`))
+  f.Add([]byte(`[]`))
+  f.Add([]byte(`null`))
+  f.Add([]byte(`{"name":null,"name":{"full":"Duplicate"}}`))
+  f.Add([]byte(`{"name":{}} garbage`))
+  f.Add([]byte(`{"Name":{"full":"Valerian Saliou"},"NAME":null}`))
+  f.Add([]byte{})
+
+  f.Fuzz(func(t *testing.T, data []byte) {
+    type mapping Person
+
+    var extra map[string]json.RawMessage
+
+    person := &Person{}
+
+    if err := unmarshalWithExtra(data, (*mapping)(person), &extra); err != nil {
+      return
+    }
+
+    person.Extra = extra
+
+    encoded, err := json.Marshal(person)
+    if err != nil {
+      t.Fatalf("decoded person could not be encoded: %v", err)
+    }
+
+    if err := json.Unmarshal(encoded, &Person{}); err != nil {
+      t.Fatalf("encoded person %q could not be decoded: %v", encoded, err)
+    }
+  })
+}
@@ -0,0 +1,392 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "reflect"
+  "sort"
+  "strconv"
+  "strings"
+)
+
+
+// ChangeKind maps the kind of a change between two records
+type ChangeKind string
+
+// Change maps a field change between two records
+//
+// The path uses IDs to identify slice items whenever available (eg. 'employments[42].title'), otherwise indexes (from the old record for removals, from the new record for additions). The pointer is the RFC 6901 pointer of the field in the old record.
+type Change struct {
+  Path     string       `json:"path"`
+  Pointer  string       `json:"pointer"`
+  Kind     ChangeKind   `json:"kind"`
+  Old      interface{}  `json:"old"`
+  New      interface{}  `json:"new"`
+}
+
+// Changes maps a list of changes
+type Changes []Change
+
+// JSONPatchOperation maps a RFC 6902 JSON patch operation
+type JSONPatchOperation struct {
+  Op     string
+  Path   string
+  Value  interface{}
+}
+
+
+// Change kinds
+const (
+  ChangeAdded ChangeKind = "added"
+  ChangeRemoved ChangeKind = "removed"
+  ChangeModified ChangeKind = "modified"
+)
+
+
+// String returns the string representation of Change
+func (instance Change) String() string {
+  return Stringify(instance)
+}
+
+
+// MarshalJSON marshals a JSON patch operation, omitting the value of removals
+func (instance JSONPatchOperation) MarshalJSON() ([]byte, error) {
+  if instance.Op == "remove" {
+    return json.Marshal(struct {
+      Op    string  `json:"op"`
+      Path  string  `json:"path"`
+    }{instance.Op, instance.Path})
+  }
+
+  return json.Marshal(struct {
+    Op     string       `json:"op"`
+    Path   string       `json:"path"`
+    Value  interface{}  `json:"value"`
+  }{instance.Op, instance.Path, instance.Value})
+}
+
+
+// Diff returns the changes between two versions of a record (eg. two *Person), walking fields by reflection
+//
+// Slice items are matched by ID whenever available, otherwise by value (thus only being added or removed).
+func Diff(old interface{}, new interface{}) (Changes, error) {
+  oldValue, newValue := reflect.ValueOf(old), reflect.ValueOf(new)
+
+  if oldValue.IsValid() == false || newValue.IsValid() == false {
+    return nil, fmt.Errorf("cannot diff nil records")
+  }
+
+  oldType, newType := oldValue.Type(), newValue.Type()
+
+  if oldType != newType {
+    return nil, fmt.Errorf("cannot diff %s with %s", oldType, newType)
+  }
+
+  // Nil records diff as empty records (eg. when a record is enriched for the first time)
+  if oldType.Kind() == reflect.Ptr {
+    oldType = oldType.Elem()
+
+    oldValue = diffElem(oldValue, oldType)
+    newValue = diffElem(newValue, oldType)
+  }
+
+  if oldType.Kind() != reflect.Struct {
+    return nil, fmt.Errorf("cannot diff %s, expected a struct", oldType)
+  }
+
+  var changes Changes
+
+  diffStruct("", "", oldValue, newValue, &changes)
+
+  return changes, nil
+}
+
+
+// Filter returns the changes at or under given paths (eg. 'employments' or 'address.city')
+func (changes Changes) Filter(paths ...string) Changes {
+  var filtered Changes
+
+  for _, change := range changes {
+    for _, path := range paths {
+      if change.Path == path || strings.HasPrefix(change.Path, path + ".") == true || strings.HasPrefix(change.Path, path + "[") == true {
+        filtered = append(filtered, change)
+        break
+      }
+    }
+  }
+
+  return filtered
+}
+
+
+// JSONPatch returns the changes as a RFC 6902 JSON patch, to be applied on the old record
+//
+// Operations are ordered so that old indexes stay valid: replacements and additions first, then removals in reverse order. Added slice items are appended, thus the order of slice items is not preserved.
+func (changes Changes) JSONPatch() []JSONPatchOperation {
+  var operations, removals []JSONPatchOperation
+
+  for _, change := range changes {
+    switch change.Kind {
+      case ChangeAdded:
+        operations = append(operations, JSONPatchOperation{Op: "add", Path: change.Pointer, Value: change.New})
+
+      case ChangeModified:
+        operations = append(operations, JSONPatchOperation{Op: "replace", Path: change.Pointer, Value: change.New})
+
+      case ChangeRemoved:
+        removals = append(removals, JSONPatchOperation{Op: "remove", Path: change.Pointer})
+    }
+  }
+
+  // Append operations (on '/-') come last, as they do not depend on indexes
+  sort.SliceStable(operations, func(i, j int) bool {
+    return strings.HasSuffix(operations[i].Path, "/-") == false && strings.HasSuffix(operations[j].Path, "/-") == true
+  })
+
+  for i := len(removals) - 1; i >= 0; i-- {
+    operations = append(operations, removals[i])
+  }
+
+  return operations
+}
+
+
+// diffStruct diffs two struct values, field by field
+func diffStruct(path string, pointer string, old reflect.Value, new reflect.Value, changes *Changes) {
+  kind := old.Type()
+
+  for i := 0; i < kind.NumField(); i++ {
+    field := kind.Field(i)
+
+    if field.PkgPath != "" {
+      continue
+    }
+
+    // Unknown fields are inlined in JSON, thus diffed key by key
+    if field.Name == "Extra" && field.Type == extraType {
+      diffExtra(path, pointer, old.Field(i), new.Field(i), changes)
+
+      continue
+    }
+
+    if name := jsonFieldName(field); name != "" {
+      diffValue(joinFieldPath(path, name), pointer + "/" + escapeJSONPointer(name), old.Field(i), new.Field(i), changes)
+    }
+  }
+}
+
+
+// diffValue diffs two values, recursing in structs and slices
+func diffValue(path string, pointer string, old reflect.Value, new reflect.Value, changes *Changes) {
+  oldEmpty, newEmpty := isDiffNil(old), isDiffNil(new)
+
+  switch {
+    case oldEmpty == true && newEmpty == true:
+      return
+
+    case oldEmpty == true:
+      *changes = append(*changes, Change{Path: path, Pointer: pointer, Kind: ChangeAdded, New: diffInterface(new)})
+      return
+
+    case newEmpty == true:
+      *changes = append(*changes, Change{Path: path, Pointer: pointer, Kind: ChangeRemoved, Old: diffInterface(old)})
+      return
+  }
+
+  if old.Kind() == reflect.Ptr {
+    old, new = old.Elem(), new.Elem()
+  }
+
+  switch old.Kind() {
+    case reflect.Struct:
+      diffStruct(path, pointer, old, new, changes)
+
+    case reflect.Slice:
+      diffSlice(path, pointer, old, new, changes)
+
+    default:
+      if reflect.DeepEqual(old.Interface(), new.Interface()) == false {
+        *changes = append(*changes, Change{Path: path, Pointer: pointer, Kind: ChangeModified, Old: old.Interface(), New: new.Interface()})
+      }
+  }
+}
+
+
+// diffSlice diffs two slices, matching items by ID (or else by value)
+func diffSlice(path string, pointer string, old reflect.Value, new reflect.Value, changes *Changes) {
+  matches := make([]int, old.Len())
+  added := make([]bool, new.Len())
+
+  for i := range matches {
+    matches[i] = -1
+  }
+
+  for j := 0; j < new.Len(); j++ {
+    added[j] = true
+
+    for i := 0; i < old.Len(); i++ {
+      if matches[i] < 0 && sameDiffItem(old.Index(i), new.Index(j)) == true {
+        matches[i], added[j] = j, false
+        break
+      }
+    }
+  }
+
+  for i := 0; i < old.Len(); i++ {
+    itemPointer := pointer + "/" + strconv.Itoa(i)
+
+    if matches[i] < 0 {
+      *changes = append(*changes, Change{Path: diffItemPath(path, old.Index(i), i), Pointer: itemPointer, Kind: ChangeRemoved, Old: diffInterface(old.Index(i))})
+    } else {
+      diffValue(diffItemPath(path, old.Index(i), i), itemPointer, old.Index(i), new.Index(matches[i]), changes)
+    }
+  }
+
+  for j := 0; j < new.Len(); j++ {
+    if added[j] == true {
+      *changes = append(*changes, Change{Path: diffItemPath(path, new.Index(j), j), Pointer: pointer + "/-", Kind: ChangeAdded, New: diffInterface(new.Index(j))})
+    }
+  }
+}
+
+
+// diffExtra diffs unknown fields, key by key
+func diffExtra(path string, pointer string, old reflect.Value, new reflect.Value, changes *Changes) {
+  var keys []string
+
+  for _, value := range []reflect.Value{old, new} {
+    for _, key := range value.MapKeys() {
+      if containsString(keys, key.String()) == false {
+        keys = append(keys, key.String())
+      }
+    }
+  }
+
+  sort.Strings(keys)
+
+  for _, key := range keys {
+    oldRaw, oldOk := old.Interface().(map[string]json.RawMessage)[key]
+    newRaw, newOk := new.Interface().(map[string]json.RawMessage)[key]
+
+    change := Change{Path: joinFieldPath(path, key), Pointer: pointer + "/" + escapeJSONPointer(key), Old: oldRaw, New: newRaw}
+
+    switch {
+      case oldOk == false:
+        change.Kind, change.Old = ChangeAdded, nil
+
+      case newOk == false:
+        change.Kind, change.New = ChangeRemoved, nil
+
+      case equalRawJSON(oldRaw, newRaw) == false:
+        change.Kind = ChangeModified
+
+      default:
+        continue
+    }
+
+    *changes = append(*changes, change)
+  }
+}
+
+
+// sameDiffItem returns whether two slice items are the same (by ID if both have one, otherwise by value)
+func sameDiffItem(old reflect.Value, new reflect.Value) bool {
+  oldID, oldOk := diffItemID(old)
+  newID, newOk := diffItemID(new)
+
+  if oldOk == true && newOk == true {
+    return oldID == newID
+  }
+
+  return reflect.DeepEqual(old.Interface(), new.Interface())
+}
+
+
+// diffItemID returns the ID of a slice item, if any
+func diffItemID(item reflect.Value) (string, bool) {
+  if item.Kind() == reflect.Ptr {
+    if item.IsNil() == true {
+      return "", false
+    }
+
+    item = item.Elem()
+  }
+
+  if item.Kind() != reflect.Struct {
+    return "", false
+  }
+
+  id := item.FieldByName("ID")
+
+  if id.IsValid() == false || id.Kind() != reflect.Ptr || id.IsNil() == true || id.Elem().Kind() != reflect.String {
+    return "", false
+  }
+
+  return id.Elem().String(), true
+}
+
+
+// diffItemPath returns the path of a slice item, using its ID if any
+func diffItemPath(path string, item reflect.Value, index int) string {
+  if id, ok := diffItemID(item); ok == true {
+    return path + "[" + id + "]"
+  }
+
+  return path + "[" + strconv.Itoa(index) + "]"
+}
+
+
+// diffElem dereferences a pointer, using the zero value for nil pointers
+func diffElem(value reflect.Value, kind reflect.Type) reflect.Value {
+  if value.IsNil() == true {
+    return reflect.Zero(kind)
+  }
+
+  return value.Elem()
+}
+
+
+// diffInterface returns the value held by a field, dereferencing pointers
+func diffInterface(value reflect.Value) interface{} {
+  if value.Kind() == reflect.Ptr && value.IsNil() == false {
+    value = value.Elem()
+  }
+
+  return value.Interface()
+}
+
+
+// isDiffNil returns whether a value is unset
+func isDiffNil(value reflect.Value) bool {
+  switch value.Kind() {
+    case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+      return value.IsNil()
+  }
+
+  return false
+}
+
+
+// equalRawJSON returns whether two raw JSON values are equal, ignoring whitespace
+func equalRawJSON(first json.RawMessage, second json.RawMessage) bool {
+  var firstBuffer, secondBuffer bytes.Buffer
+
+  if json.Compact(&firstBuffer, first) != nil || json.Compact(&secondBuffer, second) != nil {
+    return bytes.Equal(first, second)
+  }
+
+  return bytes.Equal(firstBuffer.Bytes(), secondBuffer.Bytes())
+}
+
+
+// escapeJSONPointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapeJSONPointer(key string) string {
+  return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
@@ -0,0 +1,475 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "errors"
+  "fmt"
+  "sort"
+  "strings"
+  "unicode"
+)
+
+
+type emailFormatToken int
+
+const (
+  emailFormatLiteral emailFormatToken = iota
+  emailFormatFirst
+  emailFormatLast
+  emailFormatFirstInitial
+  emailFormatLastInitial
+)
+
+const (
+  emailCandidateScoreKnown = 1.0
+  emailCandidateScoreVariant = 0.8
+  emailCandidateScoreFallback = 0.4
+  emailCandidateDecay = 0.9
+)
+
+// ErrEmailFormatEmpty is returned when parsing an empty email format
+var ErrEmailFormatEmpty = errors.New("empty email format")
+
+// emailFormatPlaceholders maps placeholder names to tokens (sorted by decreasing length for bare formats)
+var emailFormatPlaceholders = []struct{
+  name   string
+  token  emailFormatToken
+}{
+  {"first_initial", emailFormatFirstInitial},
+  {"last_initial", emailFormatLastInitial},
+  {"first_name", emailFormatFirst},
+  {"last_name", emailFormatLast},
+  {"firstname", emailFormatFirst},
+  {"lastname", emailFormatLast},
+  {"surname", emailFormatLast},
+  {"family", emailFormatLast},
+  {"given", emailFormatFirst},
+  {"first", emailFormatFirst},
+  {"last", emailFormatLast},
+  {"fi", emailFormatFirstInitial},
+  {"li", emailFormatLastInitial},
+  {"f", emailFormatFirstInitial},
+  {"l", emailFormatLastInitial},
+}
+
+// emailFormatFallbacks lists common formats, by decreasing popularity
+var emailFormatFallbacks = []string{
+  "first.last",
+  "flast",
+  "first",
+  "firstlast",
+  "first_last",
+  "firstl",
+  "f.last",
+  "last.first",
+  "last",
+  "lastf",
+  "first-last",
+}
+
+// emailFoldedRunes maps non-ASCII letters to their ASCII transliteration
+var emailFoldedRunes = map[rune]string{
+  'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+  'æ': "ae", 'ç': "c", 'ć': "c", 'ĉ': "c", 'ċ': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+  'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+  'ĝ': "g", 'ğ': "g", 'ġ': "g", 'ģ': "g", 'ĥ': "h", 'ħ': "h",
+  'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i", 'ı': "i",
+  'ĵ': "j", 'ķ': "k", 'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ŀ': "l", 'ł': "l",
+  'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n",
+  'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ŏ': "o", 'ő': "o", 'œ': "oe",
+  'ŕ': "r", 'ŗ': "r", 'ř': "r", 'ś': "s", 'ŝ': "s", 'ş': "s", 'š': "s", 'ß': "ss",
+  'ţ': "t", 'ť': "t", 'ŧ': "t", 'þ': "th",
+  'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ũ': "u", 'ū': "u", 'ŭ': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+  'ŵ': "w", 'ý': "y", 'ÿ': "y", 'ŷ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+
+// EmailFormat maps a parsed company email format (eg. 'first.last' or '{f}{last}')
+type EmailFormat struct {
+  Pattern  string
+  parts    []emailFormatPart
+}
+
+type emailFormatPart struct {
+  token    emailFormatToken
+  literal  string
+}
+
+// EmailCandidate maps a candidate email address for a person
+type EmailCandidate struct {
+  Email   string   `json:"email"`
+  Format  string   `json:"format"`
+  Score   float32  `json:"score"`
+}
+
+
+// String returns the string representation of EmailFormat
+func (instance EmailFormat) String() string {
+  return instance.Pattern
+}
+
+// String returns the string representation of EmailCandidate
+func (instance EmailCandidate) String() string {
+  return Stringify(instance)
+}
+
+
+// ParseEmailFormat parses an email format, either with placeholders (eg. '{first}.{last}') or bare (eg. 'first.last')
+func ParseEmailFormat(format string) (*EmailFormat, error) {
+  pattern := strings.ToLower(strings.TrimSpace(format))
+
+  // Ignore any domain part (eg. 'first.last@domain.com')
+  if index := strings.Index(pattern, "@"); index >= 0 {
+    pattern = pattern[:index]
+  }
+
+  if pattern == "" {
+    return nil, ErrEmailFormatEmpty
+  }
+
+  var parts []emailFormatPart
+  var err error
+
+  if strings.Contains(pattern, "{") {
+    parts, err = parseEmailFormatPlaceholders(pattern)
+  } else {
+    parts, err = parseEmailFormatBare(pattern)
+  }
+
+  if err != nil {
+    return nil, err
+  }
+
+  return &EmailFormat{Pattern: format, parts: parts}, nil
+}
+
+
+// parseEmailFormatPlaceholders parses an email format with braced placeholders
+func parseEmailFormatPlaceholders(pattern string) ([]emailFormatPart, error) {
+  var parts []emailFormatPart
+
+  for pattern != "" {
+    start := strings.Index(pattern, "{")
+    if start < 0 {
+      parts = append(parts, emailFormatPart{token: emailFormatLiteral, literal: pattern})
+      break
+    }
+    if start > 0 {
+      parts = append(parts, emailFormatPart{token: emailFormatLiteral, literal: pattern[:start]})
+    }
+
+    end := strings.Index(pattern[start:], "}")
+    if end < 0 {
+      return nil, fmt.Errorf("unterminated placeholder in email format: %q", pattern)
+    }
+
+    token, ok := lookupEmailFormatPlaceholder(pattern[start + 1:start + end])
+    if ok == false {
+      return nil, fmt.Errorf("unknown placeholder in email format: %q", pattern[start:start + end + 1])
+    }
+
+    parts = append(parts, emailFormatPart{token: token})
+    pattern = pattern[start + end + 1:]
+  }
+
+  return parts, nil
+}
+
+
+// parseEmailFormatBare parses an email format made of placeholder words and separators
+func parseEmailFormatBare(pattern string) ([]emailFormatPart, error) {
+  var parts []emailFormatPart
+
+  for pattern != "" {
+    if separator := pattern[0]; separator == '.' || separator == '_' || separator == '-' {
+      parts = append(parts, emailFormatPart{token: emailFormatLiteral, literal: string(separator)})
+      pattern = pattern[1:]
+      continue
+    }
+
+    matched := false
+
+    for _, placeholder := range emailFormatPlaceholders {
+      if strings.HasPrefix(pattern, placeholder.name) {
+        parts = append(parts, emailFormatPart{token: placeholder.token})
+        pattern = pattern[len(placeholder.name):]
+        matched = true
+        break
+      }
+    }
+
+    if matched == false {
+      return nil, fmt.Errorf("unknown word in email format: %q", pattern)
+    }
+  }
+
+  return parts, nil
+}
+
+
+// lookupEmailFormatPlaceholder returns the token for a placeholder name
+func lookupEmailFormatPlaceholder(name string) (emailFormatToken, bool) {
+  name = strings.TrimSpace(name)
+
+  for _, placeholder := range emailFormatPlaceholders {
+    if placeholder.name == name {
+      return placeholder.token, true
+    }
+  }
+
+  return emailFormatLiteral, false
+}
+
+
+// Format returns the local parts generated for a name, the most likely first
+func (format *EmailFormat) Format(name *Name) []string {
+  firsts, lasts := emailNameVariants(name)
+
+  var locals []string
+  seen := make(map[string]bool)
+
+  for _, first := range firsts {
+    for _, last := range lasts {
+      local, ok := format.render(first, last)
+
+      if ok == true && seen[local] == false {
+        seen[local] = true
+        locals = append(locals, local)
+      }
+    }
+  }
+
+  return locals
+}
+
+
+// render renders the format for a normalized first and last name
+func (format *EmailFormat) render(first string, last string) (string, bool) {
+  var local strings.Builder
+
+  for _, part := range format.parts {
+    var value string
+
+    switch part.token {
+      case emailFormatLiteral:
+        value = part.literal
+
+      case emailFormatFirst:
+        value = first
+
+      case emailFormatLast:
+        value = last
+
+      case emailFormatFirstInitial:
+        value = initial(first)
+
+      case emailFormatLastInitial:
+        value = initial(last)
+    }
+
+    if value == "" && part.token != emailFormatLiteral {
+      return "", false
+    }
+
+    local.WriteString(value)
+  }
+
+  return local.String(), local.Len() > 0
+}
+
+
+// EmailCandidates returns ranked candidate addresses for a name, given a known format (which may be empty)
+func EmailCandidates(name *Name, format string, domain string) []EmailCandidate {
+  domain = strings.ToLower(strings.TrimSpace(domain))
+
+  if name == nil || domain == "" {
+    return nil
+  }
+
+  scores := make(map[string]EmailCandidate)
+
+  add := func(pattern string, base float32) {
+    parsed, err := ParseEmailFormat(pattern)
+    if err != nil {
+      return
+    }
+
+    for i, local := range parsed.Format(name) {
+      candidate := EmailCandidate{Email: local + "@" + domain, Format: pattern, Score: base}
+
+      if i > 0 {
+        candidate.Score = base * emailCandidateScoreVariant
+      }
+
+      if existing, ok := scores[candidate.Email]; ok == false || existing.Score < candidate.Score {
+        scores[candidate.Email] = candidate
+      }
+    }
+  }
+
+  if format != "" {
+    add(format, emailCandidateScoreKnown)
+  }
+
+  score := float32(emailCandidateScoreFallback)
+
+  for _, fallback := range emailFormatFallbacks {
+    add(fallback, score)
+    score *= emailCandidateDecay
+  }
+
+  candidates := make([]EmailCandidate, 0, len(scores))
+
+  for _, candidate := range scores {
+    candidates = append(candidates, candidate)
+  }
+
+  sort.SliceStable(candidates, func(i, j int) bool {
+    if candidates[i].Score != candidates[j].Score {
+      return candidates[i].Score > candidates[j].Score
+    }
+
+    return candidates[i].Email < candidates[j].Email
+  })
+
+  return candidates
+}
+
+
+// EmailCandidates returns ranked candidate addresses for a name, at this company
+func (instance Company) EmailCandidates(name *Name) []EmailCandidate {
+  var format string
+  var domain string
+
+  if instance.Employees != nil && instance.Employees.EmailFormat != nil {
+    format = *instance.Employees.EmailFormat
+  }
+  if instance.Contact != nil && instance.Contact.Domain != nil {
+    domain = *instance.Contact.Domain
+  }
+
+  return EmailCandidates(name, format, domain)
+}
+
+
+// emailNameVariants returns normalized first and last name variants, the most likely first
+func emailNameVariants(name *Name) ([]string, []string) {
+  var firstWords []string
+  var lastWords []string
+
+  if name.First != nil {
+    firstWords = emailNameWords(*name.First)
+  }
+  if name.Last != nil {
+    lastWords = emailNameWords(*name.Last)
+  }
+
+  // Complete missing parts from the full name
+  if (len(firstWords) == 0 || len(lastWords) == 0) && name.Full != nil {
+    fullWords := emailNameWords(*name.Full)
+
+    if len(fullWords) > 0 {
+      if len(firstWords) == 0 {
+        firstWords = fullWords[:1]
+      }
+      if len(lastWords) == 0 && len(fullWords) > 1 {
+        lastWords = fullWords[1:]
+      }
+    }
+  }
+
+  return emailWordsVariants(firstWords, true), emailWordsVariants(lastWords, false)
+}
+
+
+// emailWordsVariants returns variants of a multi-part name (eg. 'van der berg' gives 'vanderberg', 'berg', 'van', 'van-der-berg')
+func emailWordsVariants(words []string, isFirst bool) []string {
+  if len(words) == 0 {
+    return []string{""}
+  }
+
+  // Hyphenated words are split into parts (eg. 'jean-pierre' gives 'jean' and 'pierre')
+  var parts []string
+
+  for _, word := range words {
+    parts = append(parts, strings.Split(word, "-")...)
+  }
+
+  variants := []string{strings.Join(parts, "")}
+
+  if len(parts) > 1 {
+    if isFirst == true {
+      variants = append(variants, parts[0])
+    } else {
+      variants = append(variants, parts[len(parts) - 1], parts[0])
+    }
+
+    // Last names are also joined with hyphens (eg. 'van-der-berg'), first names only keep their own (eg. 'jean-pierre')
+    hyphenated := strings.Join(words, "-")
+
+    if isFirst == true {
+      hyphenated = strings.Join(words, "")
+    }
+
+    if strings.Contains(hyphenated, "-") == true && containsString(variants, hyphenated) == false {
+      variants = append(variants, hyphenated)
+    }
+  }
+
+  return variants
+}
+
+
+// emailNameWords normalizes a name into lowercase ASCII words, keeping inner hyphens (eg. 'jean-pierre')
+func emailNameWords(value string) []string {
+  var normalized strings.Builder
+
+  for _, character := range strings.ToLower(value) {
+    if character < unicode.MaxASCII && (unicode.IsLetter(character) || unicode.IsDigit(character)) {
+      normalized.WriteRune(character)
+    } else if folded, ok := emailFoldedRunes[character]; ok {
+      normalized.WriteString(folded)
+    } else if character == '\'' || character == '’' {
+      // Apostrophes are dropped (eg. "O'Neil" gives 'oneil')
+      continue
+    } else if character == '-' || character == '‐' {
+      normalized.WriteRune('-')
+    } else {
+      normalized.WriteRune(' ')
+    }
+  }
+
+  var words []string
+
+  for _, word := range strings.Fields(normalized.String()) {
+    // Dangling or repeated hyphens are not part of the name (eg. 'smith - jones')
+    var subwords []string
+
+    for _, subword := range strings.Split(word, "-") {
+      if subword != "" {
+        subwords = append(subwords, subword)
+      }
+    }
+
+    if len(subwords) > 0 {
+      words = append(words, strings.Join(subwords, "-"))
+    }
+  }
+
+  return words
+}
+
+
+// initial returns the first letter of a normalized name
+func initial(value string) string {
+  if value == "" {
+    return ""
+  }
+
+  return value[:1]
+}
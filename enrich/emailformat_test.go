@@ -0,0 +1,114 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "reflect"
+  "testing"
+)
+
+
+func TestEmailFormatFormat(t *testing.T) {
+  tests := []struct {
+    format  string
+    first   string
+    last    string
+    full    string
+    want    []string
+  }{
+    {"first.last", "Valerian", "Saliou", "", []string{"valerian.saliou"}},
+    {"flast", "Valerian", "Saliou", "", []string{"vsaliou"}},
+    {"f.last", "Valerian", "Saliou", "", []string{"v.saliou"}},
+    {"firstlast", "Valerian", "Saliou", "", []string{"valeriansaliou"}},
+    {"first", "Valerian", "Saliou", "", []string{"valerian"}},
+    {"{first}{l}", "Valerian", "Saliou", "", []string{"valerians"}},
+    {"{first_initial}_{last_name}", "Valerian", "Saliou", "", []string{"v_saliou"}},
+    {"last.first@crisp.chat", "Valerian", "Saliou", "", []string{"saliou.valerian"}},
+    {"first.last", "", "", "Valerian Saliou", []string{"valerian.saliou"}},
+    {"flast", "José", "Ñúñez", "", []string{"jnunez"}},
+    {"first.last", "Zoë", "Brontë-Łukasik", "", []string{"zoe.brontelukasik", "zoe.lukasik", "zoe.bronte", "zoe.bronte-lukasik"}},
+    {"first.last", "Jean-Pierre", "Dupont", "", []string{"jeanpierre.dupont", "jean.dupont", "jean-pierre.dupont"}},
+    {"first.last", "", "", "Jean-Pierre Dupont", []string{"jeanpierre.dupont", "jean.dupont", "jean-pierre.dupont"}},
+    {"first.last", "Ludwig", "van der Berg", "", []string{"ludwig.vanderberg", "ludwig.berg", "ludwig.van", "ludwig.van-der-berg"}},
+    {"flast", "Mary Ann", "O'Neil", "", []string{"moneil"}},
+    {"first.last", "Valerian", "", "", nil},
+  }
+
+  for _, test := range tests {
+    format, err := ParseEmailFormat(test.format)
+    if err != nil {
+      t.Fatalf("ParseEmailFormat(%q) returned error: %v", test.format, err)
+    }
+
+    name := &Name{First: nonEmptyPointer(test.first), Last: nonEmptyPointer(test.last), Full: nonEmptyPointer(test.full)}
+
+    if got := format.Format(name); reflect.DeepEqual(got, test.want) == false {
+      t.Errorf("Format(%q) for %q %q %q = %q, want %q", test.format, test.first, test.last, test.full, got, test.want)
+    }
+  }
+}
+
+
+func TestParseEmailFormatInvalid(t *testing.T) {
+  tests := []string{
+    "",
+    "   ",
+    "@crisp.chat",
+    "{first",
+    "{middle}.{last}",
+    "first+last",
+    "nickname",
+    "first.last2",
+  }
+
+  for _, test := range tests {
+    if format, err := ParseEmailFormat(test); err == nil {
+      t.Errorf("ParseEmailFormat(%q) = %v, want an error", test, format)
+    }
+  }
+
+  if _, err := ParseEmailFormat(" "); err != ErrEmailFormatEmpty {
+    t.Errorf("ParseEmailFormat(\" \") returned %v, want ErrEmailFormatEmpty", err)
+  }
+}
+
+
+func TestEmailCandidates(t *testing.T) {
+  name := &Name{First: stringPointer("Jean-Pierre"), Last: stringPointer("Núñez")}
+
+  candidates := EmailCandidates(name, "{f}{last}", "Crisp.chat ")
+
+  if len(candidates) == 0 || candidates[0].Email != "jnunez@crisp.chat" || candidates[0].Format != "{f}{last}" || candidates[0].Score != emailCandidateScoreKnown {
+    t.Fatalf("EmailCandidates() top candidate = %v, want jnunez@crisp.chat from the known format", candidates)
+  }
+
+  emails := make(map[string]bool)
+
+  for i, candidate := range candidates {
+    if emails[candidate.Email] == true {
+      t.Errorf("EmailCandidates() returned %q twice", candidate.Email)
+    }
+    if i > 0 && candidate.Score > candidates[i - 1].Score {
+      t.Errorf("EmailCandidates() is not ranked at %d: %v > %v", i, candidate.Score, candidates[i - 1].Score)
+    }
+
+    emails[candidate.Email] = true
+  }
+
+  for _, want := range []string{"jeanpierre.nunez@crisp.chat", "jean-pierre.nunez@crisp.chat", "jean.nunez@crisp.chat", "jeanpierre@crisp.chat"} {
+    if emails[want] == false {
+      t.Errorf("EmailCandidates() is missing %q", want)
+    }
+  }
+
+  if candidates := EmailCandidates(name, "", ""); candidates != nil {
+    t.Errorf("EmailCandidates() without domain = %v, want nil", candidates)
+  }
+  if candidates := EmailCandidates(nil, "", "crisp.chat"); candidates != nil {
+    t.Errorf("EmailCandidates() without name = %v, want nil", candidates)
+  }
+}
@@ -0,0 +1,338 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "encoding/json"
+  "fmt"
+  "io"
+  "io/ioutil"
+  "sort"
+)
+
+
+// EmailVerdict maps the verdict of an email risk evaluation
+type EmailVerdict string
+
+// Email verdicts
+const (
+  EmailVerdictDeliverable EmailVerdict = "deliverable"
+  EmailVerdictRisky EmailVerdict = "risky"
+  EmailVerdictUndeliverable EmailVerdict = "undeliverable"
+  EmailVerdictUnknown EmailVerdict = "unknown"
+)
+
+// Email policy presets
+const (
+  EmailPolicyPresetDefault = "default"
+  EmailPolicyPresetSignupFraud = "signup_fraud"
+  EmailPolicyPresetNewsletter = "newsletter"
+  EmailPolicyPresetB2B = "b2b"
+)
+
+// emailDeliverabilityScoreMax is the deliverability score of an email raising no signal (higher is safer)
+const emailDeliverabilityScoreMax = 100
+
+// emailSignals lists all signals that can be raised from an email validation
+var emailSignals = []emailSignal{
+  {"invalid", "Email address is not valid", func(data *ValidateEmailData) *bool { return negateBool(data.Valid) }},
+  {"disposable", "Email address uses a disposable email provider", func(data *ValidateEmailData) *bool { return data.Results.Disposable }},
+  {"gibberish", "Email address looks like gibberish", func(data *ValidateEmailData) *bool { return data.Results.Gibberish }},
+  {"webmail", "Email address uses a webmail provider", func(data *ValidateEmailData) *bool { return data.Results.Webmail }},
+  {"catch_all", "Email domain accepts all addresses", func(data *ValidateEmailData) *bool { return data.Results.CatchAll }},
+  {"high_volume", "Email domain receives a high volume of email", func(data *ValidateEmailData) *bool { return data.Results.HighVolume }},
+  {"no_mx_records", "Email domain has no MX records", func(data *ValidateEmailData) *bool { return negateBool(data.Results.MXRecords) }},
+  {"no_smtp_server", "Email domain has no reachable SMTP server", func(data *ValidateEmailData) *bool { return negateBool(data.Results.SMTPServer) }},
+  {"smtp_check_failed", "SMTP server rejected the email address", func(data *ValidateEmailData) *bool { return negateBool(data.Results.SMTPCheck) }},
+  {"no_spf_policy", "Email domain has no SPF policy", func(data *ValidateEmailData) *bool { return negateBool(data.Results.SPFPolicy) }},
+  {"no_dmarc_policy", "Email domain has no DMARC policy", func(data *ValidateEmailData) *bool { return negateBool(data.Results.DMARCPolicy) }},
+  {"no_gravatar", "Email address has no Gravatar", func(data *ValidateEmailData) *bool { return negateBool(data.Results.Gravatar) }},
+}
+
+
+type emailSignal struct {
+  name     string
+  reason   string
+  extract  func(data *ValidateEmailData) *bool
+}
+
+// EmailPolicy maps a configurable email risk scoring policy
+type EmailPolicy struct {
+  Preset            string              `json:"preset,omitempty"`
+  Weights           map[string]float32  `json:"weights,omitempty"`
+  Reject            []string            `json:"reject,omitempty"`
+  MinAccuracy       float32             `json:"min_accuracy"`
+  AccuracyWeight    float32             `json:"accuracy_weight"`
+  DeliverableScore  float32             `json:"deliverable_score"`
+  RiskyScore        float32             `json:"risky_score"`
+}
+
+// EmailRisk maps the result of an email risk evaluation
+type EmailRisk struct {
+  Verdict              EmailVerdict  `json:"verdict"`
+  DeliverabilityScore  float32       `json:"deliverability_score"`
+  Signals              []string      `json:"signals,omitempty"`
+  Reasons              []string      `json:"reasons,omitempty"`
+}
+
+
+// String returns the string representation of EmailPolicy
+func (instance EmailPolicy) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of EmailRisk
+func (instance EmailRisk) String() string {
+  return Stringify(instance)
+}
+
+
+// NewEmailPolicy returns a copy of a built-in email policy preset
+func NewEmailPolicy(preset string) (*EmailPolicy, error) {
+  policy := &EmailPolicy{
+    Preset: preset,
+    Weights: map[string]float32{
+      "disposable": 60,
+      "gibberish": 30,
+      "catch_all": 20,
+      "high_volume": 5,
+      "no_smtp_server": 50,
+      "smtp_check_failed": 60,
+      "no_spf_policy": 5,
+      "no_dmarc_policy": 5,
+    },
+    Reject: []string{"invalid", "no_mx_records"},
+    MinAccuracy: 0.5,
+    AccuracyWeight: 20,
+    DeliverableScore: 70,
+    RiskyScore: 40,
+  }
+
+  switch preset {
+    case "", EmailPolicyPresetDefault:
+      policy.Preset = EmailPolicyPresetDefault
+
+    case EmailPolicyPresetSignupFraud:
+      policy.Weights["gibberish"] = 50
+      policy.Weights["no_gravatar"] = 5
+      policy.Weights["no_spf_policy"] = 10
+      policy.Weights["no_dmarc_policy"] = 10
+      policy.Reject = append(policy.Reject, "disposable")
+
+    case EmailPolicyPresetNewsletter:
+      policy.Weights["catch_all"] = 10
+      policy.Weights["gibberish"] = 15
+      policy.Reject = append(policy.Reject, "smtp_check_failed")
+      policy.DeliverableScore = 60
+
+    case EmailPolicyPresetB2B:
+      policy.Weights["catch_all"] = 10
+      policy.Reject = append(policy.Reject, "webmail", "disposable")
+
+    default:
+      return nil, fmt.Errorf("unknown email policy preset: %q", preset)
+  }
+
+  return policy, nil
+}
+
+
+// ParseEmailPolicy parses a JSON email policy, applied over the preset it names (or the default preset)
+//
+// The preset is resolved first, then each setting present in the JSON policy replaces the preset one, eg. a deliverable score of 0 accepts any email that is not rejected. A reject list replaces the preset list, while weights are set per signal, other signals keeping their preset weight.
+func ParseEmailPolicy(data []byte) (*EmailPolicy, error) {
+  preset := &struct {
+    Preset  string  `json:"preset"`
+  }{}
+
+  if err := json.Unmarshal(data, preset); err != nil {
+    return nil, err
+  }
+
+  policy, err := NewEmailPolicy(preset.Preset)
+  if err != nil {
+    return nil, err
+  }
+
+  // Absent settings keep their preset value, as decoding leaves them untouched
+  if err := json.Unmarshal(data, policy); err != nil {
+    return nil, err
+  }
+
+  policy.Preset = preset.Preset
+
+  if policy.Preset == "" {
+    policy.Preset = EmailPolicyPresetDefault
+  }
+
+  if err := policy.Validate(); err != nil {
+    return nil, err
+  }
+
+  return policy, nil
+}
+
+
+// LoadEmailPolicy reads and parses a JSON email policy
+func LoadEmailPolicy(reader io.Reader) (*EmailPolicy, error) {
+  data, err := ioutil.ReadAll(reader)
+  if err != nil {
+    return nil, err
+  }
+
+  return ParseEmailPolicy(data)
+}
+
+
+// Validate checks that a policy only refers to known signals
+func (policy *EmailPolicy) Validate() error {
+  for name := range policy.Weights {
+    if lookupEmailSignal(name) == nil {
+      return fmt.Errorf("unknown email signal in weights: %q", name)
+    }
+  }
+
+  for _, name := range policy.Reject {
+    if lookupEmailSignal(name) == nil {
+      return fmt.Errorf("unknown email signal in reject: %q", name)
+    }
+  }
+
+  if policy.RiskyScore > policy.DeliverableScore {
+    return fmt.Errorf("risky score (%v) is greater than deliverable score (%v)", policy.RiskyScore, policy.DeliverableScore)
+  }
+
+  return nil
+}
+
+
+// Evaluate scores an email validation result against the policy
+func (policy *EmailPolicy) Evaluate(data *ValidateEmailData) *EmailRisk {
+  risk := &EmailRisk{Verdict: EmailVerdictUnknown}
+
+  if data == nil || (data.Valid == nil && data.Results == nil) {
+    risk.Reasons = []string{"Email address could not be verified"}
+
+    return risk
+  }
+
+  if data.Results == nil {
+    data = &ValidateEmailData{Valid: data.Valid, Accuracy: data.Accuracy, Results: &ValidateEmailResults{}}
+  }
+
+  rejected := false
+  score := float32(emailDeliverabilityScoreMax)
+
+  for _, signal := range emailSignals {
+    value := signal.extract(data)
+    if value == nil || *value == false {
+      continue
+    }
+
+    risk.Signals = append(risk.Signals, signal.name)
+    risk.Reasons = append(risk.Reasons, signal.reason)
+
+    score -= policy.Weights[signal.name]
+
+    if containsString(policy.Reject, signal.name) == true {
+      rejected = true
+    }
+  }
+
+  if data.Accuracy != nil {
+    score -= (1 - *data.Accuracy) * policy.AccuracyWeight
+
+    if *data.Accuracy < policy.MinAccuracy {
+      risk.Signals = append(risk.Signals, "low_accuracy")
+      risk.Reasons = append(risk.Reasons, fmt.Sprintf("Verification accuracy is low (%.2f)", *data.Accuracy))
+    }
+  }
+
+  risk.DeliverabilityScore = clampScore(score, emailDeliverabilityScoreMax)
+
+  switch {
+    case rejected == true:
+      risk.Verdict = EmailVerdictUndeliverable
+
+    case data.Accuracy != nil && *data.Accuracy < policy.MinAccuracy:
+      risk.Verdict = EmailVerdictUnknown
+
+    case risk.DeliverabilityScore >= policy.DeliverableScore:
+      risk.Verdict = EmailVerdictDeliverable
+
+    case risk.DeliverabilityScore >= policy.RiskyScore:
+      risk.Verdict = EmailVerdictRisky
+
+    default:
+      risk.Verdict = EmailVerdictUndeliverable
+  }
+
+  return risk
+}
+
+
+// EmailSignals returns the names of all signals usable in email policies
+func EmailSignals() []string {
+  names := make([]string, 0, len(emailSignals))
+
+  for _, signal := range emailSignals {
+    names = append(names, signal.name)
+  }
+
+  sort.Strings(names)
+
+  return names
+}
+
+
+// lookupEmailSignal returns the signal with a given name
+func lookupEmailSignal(name string) *emailSignal {
+  for i := range emailSignals {
+    if emailSignals[i].name == name {
+      return &emailSignals[i]
+    }
+  }
+
+  return nil
+}
+
+
+// negateBool returns the negation of an optional boolean
+func negateBool(value *bool) *bool {
+  if value == nil {
+    return nil
+  }
+
+  negated := !*value
+
+  return &negated
+}
+
+
+// containsString returns whether a string is in a list
+func containsString(values []string, value string) bool {
+  for _, current := range values {
+    if current == value {
+      return true
+    }
+  }
+
+  return false
+}
+
+
+// clampScore bounds a score between zero and a maximum score
+func clampScore(score float32, max float32) float32 {
+  if score < 0 {
+    return 0
+  }
+  if score > max {
+    return max
+  }
+
+  return score
+}
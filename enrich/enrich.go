@@ -7,6 +7,8 @@ package enrich
 
 
 import (
+  "context"
+  "encoding/json"
   "fmt"
   "net/url"
 )
@@ -18,19 +20,22 @@ type EnrichService service
 
 // EnrichPersonData mapping
 type EnrichPersonData struct {
-  Person     *Person     `json:"person,omitempty"`
-  Companies  *[]Company  `json:"companies,omitempty"`
+  Person     *Person                     `json:"person,omitempty"`
+  Companies  *[]Company                  `json:"companies,omitempty"`
+  Extra      map[string]json.RawMessage  `json:"-"`
 }
 
 // EnrichCompanyData mapping
 type EnrichCompanyData struct {
-  Company  *Company  `json:"company,omitempty"`
+  Company  *Company                    `json:"company,omitempty"`
+  Extra    map[string]json.RawMessage  `json:"-"`
 }
 
 // EnrichNetworkData mapping
 type EnrichNetworkData struct {
-  Network  *Network  `json:"network,omitempty"`
-  Company  *Company  `json:"company,omitempty"`
+  Network  *Network                    `json:"network,omitempty"`
+  Company  *Company                    `json:"company,omitempty"`
+  Extra    map[string]json.RawMessage  `json:"-"`
 }
 
 
@@ -50,10 +55,50 @@ func (instance EnrichNetworkData) String() string {
 }
 
 
+// UnmarshalJSON decodes EnrichPersonData and keeps unknown fields in Extra
+func (instance *EnrichPersonData) UnmarshalJSON(data []byte) error {
+  type mapping EnrichPersonData
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes EnrichPersonData along with unknown fields from Extra
+func (instance EnrichPersonData) MarshalJSON() ([]byte, error) {
+  type mapping EnrichPersonData
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes EnrichCompanyData and keeps unknown fields in Extra
+func (instance *EnrichCompanyData) UnmarshalJSON(data []byte) error {
+  type mapping EnrichCompanyData
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes EnrichCompanyData along with unknown fields from Extra
+func (instance EnrichCompanyData) MarshalJSON() ([]byte, error) {
+  type mapping EnrichCompanyData
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes EnrichNetworkData and keeps unknown fields in Extra
+func (instance *EnrichNetworkData) UnmarshalJSON(data []byte) error {
+  type mapping EnrichNetworkData
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes EnrichNetworkData along with unknown fields from Extra
+func (instance EnrichNetworkData) MarshalJSON() ([]byte, error) {
+  type mapping EnrichNetworkData
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+
 // EnrichPersonBy enriches data on a person with personal and company information on a person.
 func (service *EnrichService) EnrichPersonBy(key string, value string) (*EnrichPersonData, *Response, error) {
   url := fmt.Sprintf("enrich/person?%s=%s", key, url.QueryEscape(value))
-  req, _ := service.client.NewRequest("GET", url, nil)
+  req, err := service.client.NewRequest("GET", url, nil)
+  if err != nil {
+    return nil, nil, err
+  }
 
   data := new(EnrichPersonData)
   resp, err := service.client.Do(req, data)
@@ -67,7 +112,10 @@ func (service *EnrichService) EnrichPersonBy(key string, value string) (*EnrichP
 // EnrichCompanyBy enriches data on a company with more information on that company.
 func (service *EnrichService) EnrichCompanyBy(key string, value string) (*EnrichCompanyData, *Response, error) {
   url := fmt.Sprintf("enrich/company?%s=%s", key, url.QueryEscape(value))
-  req, _ := service.client.NewRequest("GET", url, nil)
+  req, err := service.client.NewRequest("GET", url, nil)
+  if err != nil {
+    return nil, nil, err
+  }
 
   data := new(EnrichCompanyData)
   resp, err := service.client.Do(req, data)
@@ -80,8 +128,18 @@ func (service *EnrichService) EnrichCompanyBy(key string, value string) (*Enrich
 
 // EnrichNetworkBy enriches a network with network and company information.
 func (service *EnrichService) EnrichNetworkBy(key string, value string) (*EnrichNetworkData, *Response, error) {
+  return service.enrichNetworkBy(context.Background(), key, value)
+}
+
+// enrichNetworkBy enriches a network, cancelling the request along with a context.
+func (service *EnrichService) enrichNetworkBy(ctx context.Context, key string, value string) (*EnrichNetworkData, *Response, error) {
   url := fmt.Sprintf("enrich/network?%s=%s", key, url.QueryEscape(value))
-  req, _ := service.client.NewRequest("GET", url, nil)
+  req, err := service.client.NewRequest("GET", url, nil)
+  if err != nil {
+    return nil, nil, err
+  }
+
+  req = req.WithContext(ctx)
 
   data := new(EnrichNetworkData)
   resp, err := service.client.Do(req, data)
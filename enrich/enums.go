@@ -0,0 +1,262 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "encoding/json"
+  "strings"
+)
+
+
+// Seniority maps the seniority of an employment
+type Seniority string
+
+// Role maps the role of an employment
+type Role string
+
+// CompanyKind maps the kind of a company
+type CompanyKind string
+
+// NetworkKind maps the kind of a network
+type NetworkKind string
+
+// Gender maps the gender of a person
+type Gender string
+
+// Seniorities (from the highest to the lowest)
+const (
+  SeniorityUnknown Seniority = ""
+  SeniorityExecutive Seniority = "executive"
+  SeniorityDirector Seniority = "director"
+  SeniorityManager Seniority = "manager"
+  SenioritySenior Seniority = "senior"
+  SeniorityJunior Seniority = "junior"
+)
+
+// Roles
+const (
+  RoleUnknown Role = ""
+  RoleCustomerService Role = "customer_service"
+  RoleDesign Role = "design"
+  RoleEducation Role = "education"
+  RoleEngineering Role = "engineering"
+  RoleFinance Role = "finance"
+  RoleHealth Role = "health"
+  RoleHumanResources Role = "human_resources"
+  RoleLegal Role = "legal"
+  RoleMarketing Role = "marketing"
+  RoleMedia Role = "media"
+  RoleOperations Role = "operations"
+  RoleProduct Role = "product"
+  RolePublicRelations Role = "public_relations"
+  RoleRealEstate Role = "real_estate"
+  RoleSales Role = "sales"
+)
+
+// Company kinds
+const (
+  CompanyKindUnknown CompanyKind = ""
+  CompanyKindPrivate CompanyKind = "private"
+  CompanyKindPublic CompanyKind = "public"
+  CompanyKindEducation CompanyKind = "education"
+  CompanyKindGovernment CompanyKind = "government"
+  CompanyKindNonProfit CompanyKind = "nonprofit"
+  CompanyKindPersonal CompanyKind = "personal"
+)
+
+// Network kinds
+const (
+  NetworkKindUnknown NetworkKind = ""
+  NetworkKindIPv4 NetworkKind = "ipv4"
+  NetworkKindIPv6 NetworkKind = "ipv6"
+)
+
+// Genders
+const (
+  GenderUnknown Gender = ""
+  GenderMale Gender = "male"
+  GenderFemale Gender = "female"
+)
+
+
+// seniorityRanks maps each known seniority to its rank (the higher, the more senior)
+var seniorityRanks = map[Seniority]int{
+  SeniorityJunior: 1,
+  SenioritySenior: 2,
+  SeniorityManager: 3,
+  SeniorityDirector: 4,
+  SeniorityExecutive: 5,
+}
+
+var knownRoles = []string{
+  string(RoleCustomerService), string(RoleDesign), string(RoleEducation), string(RoleEngineering),
+  string(RoleFinance), string(RoleHealth), string(RoleHumanResources), string(RoleLegal),
+  string(RoleMarketing), string(RoleMedia), string(RoleOperations), string(RoleProduct),
+  string(RolePublicRelations), string(RoleRealEstate), string(RoleSales),
+}
+
+var knownCompanyKinds = []string{
+  string(CompanyKindPrivate), string(CompanyKindPublic), string(CompanyKindEducation),
+  string(CompanyKindGovernment), string(CompanyKindNonProfit), string(CompanyKindPersonal),
+}
+
+var knownNetworkKinds = []string{string(NetworkKindIPv4), string(NetworkKindIPv6)}
+
+var knownGenders = []string{string(GenderMale), string(GenderFemale)}
+
+
+// ParseSeniority parses a seniority (case-insensitive), preserving unseen values
+func ParseSeniority(value string) Seniority {
+  for seniority := range seniorityRanks {
+    if equalEnum(value, string(seniority)) == true {
+      return seniority
+    }
+  }
+
+  return Seniority(strings.TrimSpace(value))
+}
+
+// ParseRole parses a role (case-insensitive), preserving unseen values
+func ParseRole(value string) Role {
+  return Role(parseEnum(value, knownRoles))
+}
+
+// ParseCompanyKind parses a company kind (case-insensitive), preserving unseen values
+func ParseCompanyKind(value string) CompanyKind {
+  return CompanyKind(parseEnum(value, knownCompanyKinds))
+}
+
+// ParseNetworkKind parses a network kind (case-insensitive), preserving unseen values
+func ParseNetworkKind(value string) NetworkKind {
+  return NetworkKind(parseEnum(value, knownNetworkKinds))
+}
+
+// ParseGender parses a gender (case-insensitive), preserving unseen values
+func ParseGender(value string) Gender {
+  return Gender(parseEnum(value, knownGenders))
+}
+
+
+// Known returns whether the seniority is a known value
+func (value Seniority) Known() bool {
+  _, ok := seniorityRanks[value]
+
+  return ok
+}
+
+// Known returns whether the role is a known value
+func (value Role) Known() bool {
+  return containsString(knownRoles, string(value))
+}
+
+// Known returns whether the company kind is a known value
+func (value CompanyKind) Known() bool {
+  return containsString(knownCompanyKinds, string(value))
+}
+
+// Known returns whether the network kind is a known value
+func (value NetworkKind) Known() bool {
+  return containsString(knownNetworkKinds, string(value))
+}
+
+// Known returns whether the gender is a known value
+func (value Gender) Known() bool {
+  return containsString(knownGenders, string(value))
+}
+
+
+// Rank returns the rank of the seniority (the higher, the more senior; zero if not known)
+func (value Seniority) Rank() int {
+  return seniorityRanks[value]
+}
+
+// AtLeast returns whether the seniority is known, and equal to or above another seniority
+func (value Seniority) AtLeast(other Seniority) bool {
+  return value.Known() == true && value.Rank() >= other.Rank()
+}
+
+
+// UnmarshalJSON decodes a seniority
+func (value *Seniority) UnmarshalJSON(data []byte) error {
+  raw, err := unmarshalEnum(data)
+  if err == nil {
+    *value = ParseSeniority(raw)
+  }
+
+  return err
+}
+
+// UnmarshalJSON decodes a role
+func (value *Role) UnmarshalJSON(data []byte) error {
+  raw, err := unmarshalEnum(data)
+  if err == nil {
+    *value = ParseRole(raw)
+  }
+
+  return err
+}
+
+// UnmarshalJSON decodes a company kind
+func (value *CompanyKind) UnmarshalJSON(data []byte) error {
+  raw, err := unmarshalEnum(data)
+  if err == nil {
+    *value = ParseCompanyKind(raw)
+  }
+
+  return err
+}
+
+// UnmarshalJSON decodes a network kind
+func (value *NetworkKind) UnmarshalJSON(data []byte) error {
+  raw, err := unmarshalEnum(data)
+  if err == nil {
+    *value = ParseNetworkKind(raw)
+  }
+
+  return err
+}
+
+// UnmarshalJSON decodes a gender
+func (value *Gender) UnmarshalJSON(data []byte) error {
+  raw, err := unmarshalEnum(data)
+  if err == nil {
+    *value = ParseGender(raw)
+  }
+
+  return err
+}
+
+
+// parseEnum returns the known value matching a raw value (case-insensitive), or the raw value
+func parseEnum(value string, known []string) string {
+  for _, current := range known {
+    if equalEnum(value, current) == true {
+      return current
+    }
+  }
+
+  return strings.TrimSpace(value)
+}
+
+
+// equalEnum compares a raw value to a known value, ignoring case, spaces and dashes (eg. 'Human Resources')
+func equalEnum(value string, known string) bool {
+  normalized := strings.ToLower(strings.TrimSpace(value))
+  normalized = strings.NewReplacer(" ", "_", "-", "_").Replace(normalized)
+
+  return normalized == known
+}
+
+
+// unmarshalEnum decodes a raw enum string
+func unmarshalEnum(data []byte) (string, error) {
+  var raw string
+
+  err := json.Unmarshal(data, &raw)
+
+  return raw, err
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "errors"
+  "fmt"
+)
+
+
+var errTrailingData = errors.New("unexpected data after JSON value")
+
+
+// ResponseError maps an error response returned by the API (or by a proxy in front of it)
+type ResponseError struct {
+  StatusCode   int
+  ContentType  string
+  Reason       string
+  Message      string
+  Snippet      string
+}
+
+// DecodeError maps a response body that could not be decoded
+type DecodeError struct {
+  StatusCode  int
+  Err         error
+}
+
+// ContentTypeError maps a response body that is not JSON
+type ContentTypeError struct {
+  StatusCode   int
+  ContentType  string
+}
+
+// EmptyBodyError maps a successful response that has no body
+type EmptyBodyError struct {
+  StatusCode  int
+}
+
+
+// Error prints a response error
+func (err *ResponseError) Error() string {
+  return fmt.Sprintf("%v %v (HTTP %d)", err.Reason, err.Message, err.StatusCode)
+}
+
+// Error prints a decode error
+func (err *DecodeError) Error() string {
+  return fmt.Sprintf("decode_error Response could not be decoded (HTTP %d): %s", err.StatusCode, err.Err)
+}
+
+// Unwrap returns the underlying decoding error
+func (err *DecodeError) Unwrap() error {
+  return err.Err
+}
+
+// Error prints a content type error
+func (err *ContentTypeError) Error() string {
+  return fmt.Sprintf("invalid_content_type Response is not JSON (HTTP %d): %s", err.StatusCode, err.ContentType)
+}
+
+// Error prints an empty body error
+func (err *EmptyBodyError) Error() string {
+  return fmt.Sprintf("empty_body Response has no body (HTTP %d)", err.StatusCode)
+}
@@ -0,0 +1,204 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "reflect"
+  "sort"
+  "strings"
+  "sync"
+)
+
+
+var knownFieldsCache sync.Map
+
+
+// UnknownFieldsError lists response fields that are not mapped by the library
+type UnknownFieldsError struct {
+  Fields  []string
+}
+
+
+// Error prints an unknown fields error
+func (err *UnknownFieldsError) Error() string {
+  return fmt.Sprintf("unknown_fields Response contains unmapped fields: %s", strings.Join(err.Fields, ", "))
+}
+
+
+// unmarshalWithExtra decodes data into mapping, and stores unknown keys into extra
+func unmarshalWithExtra(data []byte, mapping interface{}, extra *map[string]json.RawMessage) error {
+  if err := json.Unmarshal(data, mapping); err != nil {
+    return err
+  }
+
+  var fields map[string]json.RawMessage
+
+  if err := json.Unmarshal(data, &fields); err != nil {
+    return err
+  }
+
+  known := knownFields(reflect.TypeOf(mapping).Elem())
+
+  // Keys are matched to fields case-insensitively, as done by encoding/json
+  for key := range fields {
+    if _, ok := known[strings.ToLower(key)]; ok == true {
+      delete(fields, key)
+    }
+  }
+
+  if len(fields) > 0 {
+    *extra = fields
+  } else {
+    *extra = nil
+  }
+
+  return nil
+}
+
+
+// marshalWithExtra encodes mapping, and appends unknown keys from extra
+func marshalWithExtra(mapping interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+  data, err := json.Marshal(mapping)
+  if err != nil || len(extra) == 0 {
+    return data, err
+  }
+
+  known := knownFields(reflect.TypeOf(mapping))
+  keys := make([]string, 0, len(extra))
+
+  for key := range extra {
+    // Mapped fields always take precedence over stale extra values
+    if _, ok := known[strings.ToLower(key)]; ok == false {
+      keys = append(keys, key)
+    }
+  }
+
+  sort.Strings(keys)
+
+  var buf bytes.Buffer
+
+  buf.Write(data[:len(data) - 1])
+
+  for i, key := range keys {
+    if i > 0 || len(data) > 2 {
+      buf.WriteByte(',')
+    }
+
+    name, _ := json.Marshal(key)
+
+    buf.Write(name)
+    buf.WriteByte(':')
+
+    if len(extra[key]) == 0 {
+      buf.WriteString("null")
+    } else {
+      buf.Write(extra[key])
+    }
+  }
+
+  buf.WriteByte('}')
+
+  return buf.Bytes(), nil
+}
+
+
+// knownFields returns the JSON keys mapped by a struct type, lower-cased
+func knownFields(kind reflect.Type) map[string]struct{} {
+  if cached, ok := knownFieldsCache.Load(kind); ok {
+    return cached.(map[string]struct{})
+  }
+
+  fields := make(map[string]struct{})
+
+  for i := 0; i < kind.NumField(); i++ {
+    if name := jsonFieldName(kind.Field(i)); name != "" {
+      fields[strings.ToLower(name)] = struct{}{}
+    }
+  }
+
+  knownFieldsCache.Store(kind, fields)
+
+  return fields
+}
+
+
+// jsonFieldName returns the JSON key of a struct field, or an empty string if ignored
+func jsonFieldName(field reflect.StructField) string {
+  if field.PkgPath != "" {
+    return ""
+  }
+
+  tag := field.Tag.Get("json")
+  if tag == "-" {
+    return ""
+  }
+
+  if name := strings.Split(tag, ",")[0]; name != "" {
+    return name
+  }
+
+  return field.Name
+}
+
+
+// checkUnknownFields returns an error listing all unknown fields kept in a decoded value
+func checkUnknownFields(instance interface{}) error {
+  var fields []string
+
+  collectUnknownFields(reflect.ValueOf(instance), "", &fields)
+
+  if len(fields) > 0 {
+    sort.Strings(fields)
+
+    return &UnknownFieldsError{Fields: fields}
+  }
+
+  return nil
+}
+
+
+// collectUnknownFields walks a value and collects paths to unknown fields
+func collectUnknownFields(val reflect.Value, path string, fields *[]string) {
+  if val.Kind() == reflect.Ptr && val.IsNil() {
+    return
+  }
+
+  v := reflect.Indirect(val)
+
+  switch v.Kind() {
+    case reflect.Slice:
+      for i := 0; i < v.Len(); i++ {
+        collectUnknownFields(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fields)
+      }
+
+    case reflect.Struct:
+      for i := 0; i < v.NumField(); i++ {
+        field := v.Type().Field(i)
+
+        if field.Name == "Extra" && field.Type == reflect.TypeOf(map[string]json.RawMessage{}) {
+          for key := range v.Field(i).Interface().(map[string]json.RawMessage) {
+            *fields = append(*fields, joinFieldPath(path, key))
+          }
+        } else if name := jsonFieldName(field); name != "" {
+          collectUnknownFields(v.Field(i), joinFieldPath(path, name), fields)
+        }
+      }
+  }
+}
+
+
+// joinFieldPath appends a key to a dotted field path
+func joinFieldPath(path string, key string) string {
+  if path == "" {
+    return key
+  }
+
+  return path + "." + key
+}
@@ -0,0 +1,222 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "errors"
+  "strings"
+)
+
+
+const (
+  defaultFindEmailMaxChecks = 5
+  defaultFindEmailMinAccuracy = 0.8
+  findEmailCatchAllPenalty = 0.5
+  findEmailKnownScore = 1.0
+)
+
+// ErrEmailNotFound is returned when no candidate email could be verified
+var ErrEmailNotFound = errors.New("not_found No email address could be found.")
+
+
+// FinderService service
+type FinderService service
+
+
+// FindEmailOptions mapping
+type FindEmailOptions struct {
+  MaxChecks    int
+  MinAccuracy  float32
+}
+
+// FindEmailData mapping
+type FindEmailData struct {
+  Email       string            `json:"email"`
+  Confidence  float32           `json:"confidence"`
+  CatchAll    bool              `json:"catch_all"`
+  Company     *Company          `json:"company,omitempty"`
+  Checks      []FindEmailCheck  `json:"checks,omitempty"`
+}
+
+// FindEmailCheck mapping
+type FindEmailCheck struct {
+  Candidate   EmailCandidate      `json:"candidate"`
+  Validation  *ValidateEmailData  `json:"validation,omitempty"`
+}
+
+
+// String returns the string representation of FindEmailData
+func (instance FindEmailData) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of FindEmailCheck
+func (instance FindEmailCheck) String() string {
+  return Stringify(instance)
+}
+
+
+// FindEmail finds the email address of a person at a company domain, using default options.
+func (service *FinderService) FindEmail(name *Name, domain string) (*FindEmailData, error) {
+  return service.FindEmailWithOptions(name, domain, FindEmailOptions{})
+}
+
+
+// FindEmailWithOptions finds the email address of a person at a company domain.
+func (service *FinderService) FindEmailWithOptions(name *Name, domain string, options FindEmailOptions) (*FindEmailData, error) {
+  if options.MaxChecks <= 0 {
+    options.MaxChecks = defaultFindEmailMaxChecks
+  }
+  if options.MinAccuracy <= 0 {
+    options.MinAccuracy = defaultFindEmailMinAccuracy
+  }
+
+  company, err := service.findCompany(domain)
+  if err != nil {
+    return nil, err
+  }
+
+  data := &FindEmailData{Company: company}
+  candidates := findEmailKnownCandidates(company, name)
+
+  for _, candidate := range company.EmailCandidates(name) {
+    if containsEmailCandidate(candidates, candidate.Email) == false {
+      candidates = append(candidates, candidate)
+    }
+  }
+
+  best := -1
+
+  for _, candidate := range candidates {
+    if len(data.Checks) >= options.MaxChecks {
+      break
+    }
+
+    validation, _, err := service.client.Verify.ValidateEmail(candidate.Email)
+    if err != nil {
+      return nil, err
+    }
+
+    data.Checks = append(data.Checks, FindEmailCheck{Candidate: candidate, Validation: validation})
+
+    // Catch-all domains accept any address, thus further checks are meaningless
+    if validation.Results != nil && validation.Results.CatchAll != nil && *validation.Results.CatchAll == true {
+      data.CatchAll = true
+      data.Email = candidates[0].Email
+      data.Confidence = candidates[0].Score * findEmailAccuracy(validation) * findEmailCatchAllPenalty
+
+      return data, nil
+    }
+
+    if validation.Valid == nil || *validation.Valid == false {
+      continue
+    }
+
+    if best < 0 || findEmailAccuracy(validation) > findEmailAccuracy(data.Checks[best].Validation) {
+      best = len(data.Checks) - 1
+    }
+
+    // Stop early on a confident hit
+    if findEmailAccuracy(validation) >= options.MinAccuracy {
+      break
+    }
+  }
+
+  if best < 0 {
+    return data, ErrEmailNotFound
+  }
+
+  data.Email = data.Checks[best].Candidate.Email
+  data.Confidence = findEmailAccuracy(data.Checks[best].Validation)
+
+  return data, nil
+}
+
+
+// findCompany enriches the company for a domain, falling back to a bare company if it is unknown
+func (service *FinderService) findCompany(domain string) (*Company, error) {
+  domain = strings.ToLower(strings.TrimSpace(domain))
+
+  companyData, _, err := service.client.Enrich.EnrichCompanyBy("domain", domain)
+  if err != nil {
+    if responseError, ok := err.(*ResponseError); ok == false || responseError.Reason != "not_found" {
+      return nil, err
+    }
+  }
+
+  company := &Company{}
+
+  if companyData != nil && companyData.Company != nil {
+    company = companyData.Company
+  }
+
+  if company.Contact == nil || company.Contact.Domain == nil || *company.Contact.Domain == "" {
+    contact := Contact{}
+
+    if company.Contact != nil {
+      contact = *company.Contact
+    }
+
+    contact.Domain = &domain
+
+    company.Contact = &contact
+  }
+
+  return company, nil
+}
+
+
+// findEmailKnownCandidates returns emails of company employees matching a name
+func findEmailKnownCandidates(company *Company, name *Name) []EmailCandidate {
+  var candidates []EmailCandidate
+
+  if company.Employees == nil || company.Employees.Persons == nil || name == nil {
+    return nil
+  }
+
+  firsts, lasts := emailNameVariants(name)
+
+  for _, person := range *company.Employees.Persons {
+    if person.Name == nil || person.Contact == nil || person.Contact.Emails == nil {
+      continue
+    }
+
+    personFirsts, personLasts := emailNameVariants(person.Name)
+
+    if firsts[0] == "" || firsts[0] != personFirsts[0] || lasts[0] != personLasts[0] {
+      continue
+    }
+
+    for _, email := range *person.Contact.Emails {
+      candidates = append(candidates, EmailCandidate{Email: strings.ToLower(email), Format: "known", Score: findEmailKnownScore})
+    }
+  }
+
+  return candidates
+}
+
+
+// containsEmailCandidate returns whether an email is already in a candidate list
+func containsEmailCandidate(candidates []EmailCandidate, email string) bool {
+  for _, candidate := range candidates {
+    if candidate.Email == email {
+      return true
+    }
+  }
+
+  return false
+}
+
+
+// findEmailAccuracy returns the accuracy of a validation result
+func findEmailAccuracy(validation *ValidateEmailData) float32 {
+  if validation == nil || validation.Accuracy == nil {
+    return 0
+  }
+
+  return *validation.Accuracy
+}
@@ -6,69 +6,80 @@
 package enrich
 
 
+import (
+  "encoding/json"
+)
+
+
 // Person mapping
 type Person struct {
-  ID           *string              `json:"id,omitempty"`
-  Name         *Name                `json:"name,omitempty"`
-  Avatar       *string              `json:"avatar,omitempty"`
-  Gender       *string              `json:"gender,omitempty"`
-  Description  *string              `json:"description,omitempty"`
-  Timezone     *string              `json:"timezone,omitempty"`
-  Contact      *Contact             `json:"contact,omitempty"`
-  Social       *PersonSocial        `json:"social,omitempty"`
-  Address      *Address             `json:"address,omitempty"`
-  Employments  *[]PersonEmployment  `json:"employments,omitempty"`
-  Geolocation  *Geolocation         `json:"geolocation,omitempty"`
-  Locales      *[]string            `json:"locales,omitempty"`
+  ID           *string                     `json:"id,omitempty"`
+  Name         *Name                       `json:"name,omitempty"`
+  Avatar       *string                     `json:"avatar,omitempty"`
+  Gender       *Gender                     `json:"gender,omitempty"`
+  Description  *string                     `json:"description,omitempty"`
+  Timezone     *string                     `json:"timezone,omitempty"`
+  Contact      *Contact                    `json:"contact,omitempty"`
+  Social       *PersonSocial               `json:"social,omitempty"`
+  Address      *Address                    `json:"address,omitempty"`
+  Employments  *[]PersonEmployment         `json:"employments,omitempty"`
+  Geolocation  *Geolocation                `json:"geolocation,omitempty"`
+  Locales      *[]string                   `json:"locales,omitempty"`
+  Extra        map[string]json.RawMessage  `json:"-"`
 }
 
 // PersonSocial mapping
 type PersonSocial struct {
-  Facebook   *PersonSocialNetwork  `json:"facebook,omitempty"`
-  Twitter    *PersonSocialNetwork  `json:"twitter,omitempty"`
-  LinkedIn   *PersonSocialNetwork  `json:"linkedin,omitempty"`
-  GitHub     *PersonSocialNetwork  `json:"github,omitempty"`
-  YouTube    *PersonSocialNetwork  `json:"youtube,omitempty"`
-  Instagram  *PersonSocialNetwork  `json:"instagram,omitempty"`
+  Facebook   *PersonSocialNetwork        `json:"facebook,omitempty"`
+  Twitter    *PersonSocialNetwork        `json:"twitter,omitempty"`
+  LinkedIn   *PersonSocialNetwork        `json:"linkedin,omitempty"`
+  GitHub     *PersonSocialNetwork        `json:"github,omitempty"`
+  YouTube    *PersonSocialNetwork        `json:"youtube,omitempty"`
+  Instagram  *PersonSocialNetwork        `json:"instagram,omitempty"`
+  Extra      map[string]json.RawMessage  `json:"-"`
 }
 
 // PersonSocialNetwork mapping
 type PersonSocialNetwork struct {
-  Handle  *string  `json:"handle,omitempty"`
-  URL     *string  `json:"url,omitempty"`
+  Handle  *string                     `json:"handle,omitempty"`
+  URL     *string                     `json:"url,omitempty"`
+  Extra   map[string]json.RawMessage  `json:"-"`
 }
 
 // PersonEmployment mapping
 type PersonEmployment struct {
-  ID         *string  `json:"id,omitempty"`
-  Name       *string  `json:"name,omitempty"`
-  Domain     *string  `json:"domain,omitempty"`
-  Title      *string  `json:"title,omitempty"`
-  Role       *string  `json:"role,omitempty"`
-  Seniority  *string  `json:"seniority,omitempty"`
+  ID         *string                     `json:"id,omitempty"`
+  Name       *string                     `json:"name,omitempty"`
+  Domain     *string                     `json:"domain,omitempty"`
+  Title      *string                     `json:"title,omitempty"`
+  Role       *Role                       `json:"role,omitempty"`
+  Seniority  *Seniority                  `json:"seniority,omitempty"`
+  Extra      map[string]json.RawMessage  `json:"-"`
 }
 
 // Company mapping
 type Company struct {
-  ID           *string            `json:"id,omitempty"`
-  Name         *string            `json:"name,omitempty"`
-  LegalName    *string            `json:"legal_name,omitempty"`
-  Logo         *string            `json:"logo,omitempty"`
-  Description  *string            `json:"description,omitempty"`
-  Kind         *string            `json:"kind,omitempty"`
-  Founded      *uint16            `json:"founded,omitempty"`
-  Timezone     *string            `json:"timezone,omitempty"`
-  Contact      *Contact           `json:"contact,omitempty"`
-  Category     *CompanyCategory   `json:"category,omitempty"`
-  Address      *Address           `json:"address,omitempty"`
-  Metrics      *CompanyMetrics    `json:"metrics,omitempty"`
-  Employees    *CompanyEmployees  `json:"employees,omitempty"`
+  ID           *string                     `json:"id,omitempty"`
+  Name         *string                     `json:"name,omitempty"`
+  LegalName    *string                     `json:"legal_name,omitempty"`
+  Logo         *string                     `json:"logo,omitempty"`
+  Description  *string                     `json:"description,omitempty"`
+  Kind         *CompanyKind                `json:"kind,omitempty"`
+  Founded      *uint16                     `json:"founded,omitempty"`
+  Timezone     *string                     `json:"timezone,omitempty"`
+  Contact      *Contact                    `json:"contact,omitempty"`
+  Category     *CompanyCategory            `json:"category,omitempty"`
+  Address      *Address                    `json:"address,omitempty"`
+  Metrics      *CompanyMetrics             `json:"metrics,omitempty"`
+  Employees    *CompanyEmployees           `json:"employees,omitempty"`
+  Extra        map[string]json.RawMessage  `json:"-"`
 }
 
 // CompanyCategory mapping
 type CompanyCategory struct {
-  Industry      *string    `json:"industry,omitempty"`
-  Specialities  *[]string  `json:"specialities,omitempty"`
+  Industry      *string                     `json:"industry,omitempty"`
+  Specialities  *[]string                   `json:"specialities,omitempty"`
+  Extra         map[string]json.RawMessage  `json:"-"`
 }
 
 // CompanyMetrics mapping
@@ -77,18 +88,21 @@ type CompanyMetrics struct {
   Employees         *[]uint32                     `json:"employees,omitempty"`
   FacebookLikes     *uint32                       `json:"facebook_likes,omitempty"`
   TwitterFollowers  *uint32                       `json:"twitter_followers,omitempty"`
+  Extra             map[string]json.RawMessage    `json:"-"`
 }
 
 // CompanyMetricsAnnualRevenue mapping
 type CompanyMetricsAnnualRevenue struct {
-  Amount    *int64   `json:"amount,omitempty"`
-  Currency  *string  `json:"currency,omitempty"`
+  Amount    *int64                      `json:"amount,omitempty"`
+  Currency  *string                     `json:"currency,omitempty"`
+  Extra     map[string]json.RawMessage  `json:"-"`
 }
 
 // CompanyEmployees mapping
 type CompanyEmployees struct {
-  EmailFormat  *string                    `json:"email_format,omitempty"`
-  Persons      *[]CompanyEmployeesPerson  `json:"persons,omitempty"`
+  EmailFormat  *string                     `json:"email_format,omitempty"`
+  Persons      *[]CompanyEmployeesPerson   `json:"persons,omitempty"`
+  Extra        map[string]json.RawMessage  `json:"-"`
 }
 
 // CompanyEmployeesPerson mapping
@@ -97,106 +111,119 @@ type CompanyEmployeesPerson struct {
   Name        *Name                              `json:"name,omitempty"`
   Employment  *CompanyEmployeesPersonEmployment  `json:"employment,omitempty"`
   Contact     *Contact                           `json:"contact,omitempty"`
+  Extra       map[string]json.RawMessage         `json:"-"`
 }
 
 // CompanyEmployeesPersonEmployment mapping
 type CompanyEmployeesPersonEmployment struct {
-  Title      *string  `json:"title,omitempty"`
-  Role       *string  `json:"role,omitempty"`
-  Seniority  *string  `json:"seniority,omitempty"`
+  Title      *string                     `json:"title,omitempty"`
+  Role       *Role                       `json:"role,omitempty"`
+  Seniority  *Seniority                  `json:"seniority,omitempty"`
+  Extra      map[string]json.RawMessage  `json:"-"`
 }
 
 // Network mapping
 type Network struct {
-  ID           *string          `json:"id,omitempty"`
-  IP           *string          `json:"ip,omitempty"`
-  Kind         *string          `json:"kind,omitempty"`
-  Host         *NetworkHost     `json:"host,omitempty"`
-  Reverse      *NetworkReverse  `json:"reverse,omitempty"`
-  Geolocation  *Geolocation     `json:"geolocation,omitempty"`
-  Block        *NetworkBlock    `json:"block,omitempty"`
-  Usage        *NetworkUsage    `json:"usage,omitempty"`
+  ID           *string                     `json:"id,omitempty"`
+  IP           *string                     `json:"ip,omitempty"`
+  Kind         *NetworkKind                `json:"kind,omitempty"`
+  Host         *NetworkHost                `json:"host,omitempty"`
+  Reverse      *NetworkReverse             `json:"reverse,omitempty"`
+  Geolocation  *Geolocation                `json:"geolocation,omitempty"`
+  Block        *NetworkBlock               `json:"block,omitempty"`
+  Usage        *NetworkUsage               `json:"usage,omitempty"`
+  Extra        map[string]json.RawMessage  `json:"-"`
 }
 
 // NetworkHost mapping
 type NetworkHost struct {
-  Reachable  *bool  `json:"reachable,omitempty"`
+  Reachable  *bool                       `json:"reachable,omitempty"`
+  Extra      map[string]json.RawMessage  `json:"-"`
 }
 
 // NetworkReverse mapping
 type NetworkReverse struct {
-  Hostname  *string  `json:"hostname,omitempty"`
-  Matches   *bool    `json:"matches,omitempty"`
+  Hostname  *string                     `json:"hostname,omitempty"`
+  Matches   *bool                       `json:"matches,omitempty"`
+  Extra     map[string]json.RawMessage  `json:"-"`
 }
 
 // NetworkUsage mapping
 type NetworkUsage struct {
-  Home    *bool  `json:"home,omitempty"`
-  Office  *bool  `json:"office,omitempty"`
-  Mobile  *bool  `json:"mobile,omitempty"`
-  Server  *bool  `json:"server,omitempty"`
-  TOR     *bool  `json:"tor,omitempty"`
-  VPN     *bool  `json:"vpn,omitempty"`
+  Home    *bool                       `json:"home,omitempty"`
+  Office  *bool                       `json:"office,omitempty"`
+  Mobile  *bool                       `json:"mobile,omitempty"`
+  Server  *bool                       `json:"server,omitempty"`
+  TOR     *bool                       `json:"tor,omitempty"`
+  VPN     *bool                       `json:"vpn,omitempty"`
+  Extra   map[string]json.RawMessage  `json:"-"`
 }
 
 // NetworkBlock mapping
 type NetworkBlock struct {
-  Name   *string             `json:"name,omitempty"`
-  Range  *string             `json:"range,omitempty"`
-  Owner  *NetworkBlockOwner  `json:"owner,omitempty"`
+  Name   *string                     `json:"name,omitempty"`
+  Range  *string                     `json:"range,omitempty"`
+  Owner  *NetworkBlockOwner          `json:"owner,omitempty"`
+  Extra  map[string]json.RawMessage  `json:"-"`
 }
 
 // NetworkBlockOwner mapping
 type NetworkBlockOwner struct {
-  Organization  *string   `json:"organization,omitempty"`
-  Person        *string   `json:"person,omitempty"`
-  Contact       *Contact  `json:"contact,omitempty"`
-  Address       *Address  `json:"address,omitempty"`
+  Organization  *string                     `json:"organization,omitempty"`
+  Person        *string                     `json:"person,omitempty"`
+  Contact       *Contact                    `json:"contact,omitempty"`
+  Address       *Address                    `json:"address,omitempty"`
+  Extra         map[string]json.RawMessage  `json:"-"`
 }
 
 // Contact mapping
 type Contact struct {
-  Domain      *string    `json:"domain,omitempty"`
-  Website     *string    `json:"website,omitempty"`
-  Facebook    *string    `json:"facebook,omitempty"`
-  Twitter     *string    `json:"twitter,omitempty"`
-  LinkedIn    *string    `json:"linkedin,omitempty"`
-  YouTube     *string    `json:"youtube,omitempty"`
-  Instagram   *string    `json:"instagram,omitempty"`
-  Emails      *[]string  `json:"emails,omitempty"`
-  Phones      *[]string  `json:"phones,omitempty"`
-  LinkedInID  *string    `json:"linkedin_id,omitempty"`
+  Domain      *string                     `json:"domain,omitempty"`
+  Website     *string                     `json:"website,omitempty"`
+  Facebook    *string                     `json:"facebook,omitempty"`
+  Twitter     *string                     `json:"twitter,omitempty"`
+  LinkedIn    *string                     `json:"linkedin,omitempty"`
+  YouTube     *string                     `json:"youtube,omitempty"`
+  Instagram   *string                     `json:"instagram,omitempty"`
+  Emails      *[]string                   `json:"emails,omitempty"`
+  Phones      *[]string                   `json:"phones,omitempty"`
+  LinkedInID  *string                     `json:"linkedin_id,omitempty"`
+  Extra       map[string]json.RawMessage  `json:"-"`
 }
 
 // Address mapping
 type Address struct {
-  Street       *string       `json:"street,omitempty"`
-  Postcode     *string       `json:"postcode,omitempty"`
-  City         *string       `json:"city,omitempty"`
-  Region       *string       `json:"region,omitempty"`
-  Country      *string       `json:"country,omitempty"`
-  Coordinates  *Coordinates  `json:"coordinates,omitempty"`
+  Street       *string                     `json:"street,omitempty"`
+  Postcode     *string                     `json:"postcode,omitempty"`
+  City         *string                     `json:"city,omitempty"`
+  Region       *string                     `json:"region,omitempty"`
+  Country      *string                     `json:"country,omitempty"`
+  Coordinates  *Coordinates                `json:"coordinates,omitempty"`
+  Extra        map[string]json.RawMessage  `json:"-"`
 }
 
 // Geolocation mapping
 type Geolocation struct {
-  Country      *string       `json:"country,omitempty"`
-  Region       *string       `json:"region,omitempty"`
-  City         *string       `json:"city,omitempty"`
-  Coordinates  *Coordinates  `json:"coordinates,omitempty"`
+  Country      *string                     `json:"country,omitempty"`
+  Region       *string                     `json:"region,omitempty"`
+  City         *string                     `json:"city,omitempty"`
+  Coordinates  *Coordinates                `json:"coordinates,omitempty"`
+  Extra        map[string]json.RawMessage  `json:"-"`
 }
 
 // Name mapping
 type Name struct {
-  Full   *string  `json:"full,omitempty"`
-  First  *string  `json:"first,omitempty"`
-  Last   *string  `json:"last,omitempty"`
+  Full   *string                     `json:"full,omitempty"`
+  First  *string                     `json:"first,omitempty"`
+  Last   *string                     `json:"last,omitempty"`
+  Extra  map[string]json.RawMessage  `json:"-"`
 }
 
 // Coordinates mapping
 type Coordinates struct {
-  Latitude   *float32  `json:"latitude,omitempty"`
-  Longitude  *float32  `json:"longitude,omitempty"`
+  Latitude   *float32                    `json:"latitude,omitempty"`
+  Longitude  *float32                    `json:"longitude,omitempty"`
+  Extra      map[string]json.RawMessage  `json:"-"`
 }
 
 
@@ -309,3 +336,269 @@ func (instance Name) String() string {
 func (instance Coordinates) String() string {
   return Stringify(instance)
 }
+
+
+
+// UnmarshalJSON decodes Person and keeps unknown fields in Extra
+func (instance *Person) UnmarshalJSON(data []byte) error {
+  type mapping Person
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes Person along with unknown fields from Extra
+func (instance Person) MarshalJSON() ([]byte, error) {
+  type mapping Person
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes PersonSocial and keeps unknown fields in Extra
+func (instance *PersonSocial) UnmarshalJSON(data []byte) error {
+  type mapping PersonSocial
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes PersonSocial along with unknown fields from Extra
+func (instance PersonSocial) MarshalJSON() ([]byte, error) {
+  type mapping PersonSocial
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes PersonSocialNetwork and keeps unknown fields in Extra
+func (instance *PersonSocialNetwork) UnmarshalJSON(data []byte) error {
+  type mapping PersonSocialNetwork
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes PersonSocialNetwork along with unknown fields from Extra
+func (instance PersonSocialNetwork) MarshalJSON() ([]byte, error) {
+  type mapping PersonSocialNetwork
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes PersonEmployment and keeps unknown fields in Extra
+func (instance *PersonEmployment) UnmarshalJSON(data []byte) error {
+  type mapping PersonEmployment
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes PersonEmployment along with unknown fields from Extra
+func (instance PersonEmployment) MarshalJSON() ([]byte, error) {
+  type mapping PersonEmployment
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes Company and keeps unknown fields in Extra
+func (instance *Company) UnmarshalJSON(data []byte) error {
+  type mapping Company
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes Company along with unknown fields from Extra
+func (instance Company) MarshalJSON() ([]byte, error) {
+  type mapping Company
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes CompanyCategory and keeps unknown fields in Extra
+func (instance *CompanyCategory) UnmarshalJSON(data []byte) error {
+  type mapping CompanyCategory
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes CompanyCategory along with unknown fields from Extra
+func (instance CompanyCategory) MarshalJSON() ([]byte, error) {
+  type mapping CompanyCategory
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes CompanyMetrics and keeps unknown fields in Extra
+func (instance *CompanyMetrics) UnmarshalJSON(data []byte) error {
+  type mapping CompanyMetrics
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes CompanyMetrics along with unknown fields from Extra
+func (instance CompanyMetrics) MarshalJSON() ([]byte, error) {
+  type mapping CompanyMetrics
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes CompanyMetricsAnnualRevenue and keeps unknown fields in Extra
+func (instance *CompanyMetricsAnnualRevenue) UnmarshalJSON(data []byte) error {
+  type mapping CompanyMetricsAnnualRevenue
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes CompanyMetricsAnnualRevenue along with unknown fields from Extra
+func (instance CompanyMetricsAnnualRevenue) MarshalJSON() ([]byte, error) {
+  type mapping CompanyMetricsAnnualRevenue
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes CompanyEmployees and keeps unknown fields in Extra
+func (instance *CompanyEmployees) UnmarshalJSON(data []byte) error {
+  type mapping CompanyEmployees
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes CompanyEmployees along with unknown fields from Extra
+func (instance CompanyEmployees) MarshalJSON() ([]byte, error) {
+  type mapping CompanyEmployees
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes CompanyEmployeesPerson and keeps unknown fields in Extra
+func (instance *CompanyEmployeesPerson) UnmarshalJSON(data []byte) error {
+  type mapping CompanyEmployeesPerson
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes CompanyEmployeesPerson along with unknown fields from Extra
+func (instance CompanyEmployeesPerson) MarshalJSON() ([]byte, error) {
+  type mapping CompanyEmployeesPerson
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes CompanyEmployeesPersonEmployment and keeps unknown fields in Extra
+func (instance *CompanyEmployeesPersonEmployment) UnmarshalJSON(data []byte) error {
+  type mapping CompanyEmployeesPersonEmployment
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes CompanyEmployeesPersonEmployment along with unknown fields from Extra
+func (instance CompanyEmployeesPersonEmployment) MarshalJSON() ([]byte, error) {
+  type mapping CompanyEmployeesPersonEmployment
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes Network and keeps unknown fields in Extra
+func (instance *Network) UnmarshalJSON(data []byte) error {
+  type mapping Network
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes Network along with unknown fields from Extra
+func (instance Network) MarshalJSON() ([]byte, error) {
+  type mapping Network
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes NetworkHost and keeps unknown fields in Extra
+func (instance *NetworkHost) UnmarshalJSON(data []byte) error {
+  type mapping NetworkHost
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes NetworkHost along with unknown fields from Extra
+func (instance NetworkHost) MarshalJSON() ([]byte, error) {
+  type mapping NetworkHost
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes NetworkReverse and keeps unknown fields in Extra
+func (instance *NetworkReverse) UnmarshalJSON(data []byte) error {
+  type mapping NetworkReverse
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes NetworkReverse along with unknown fields from Extra
+func (instance NetworkReverse) MarshalJSON() ([]byte, error) {
+  type mapping NetworkReverse
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes NetworkUsage and keeps unknown fields in Extra
+func (instance *NetworkUsage) UnmarshalJSON(data []byte) error {
+  type mapping NetworkUsage
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes NetworkUsage along with unknown fields from Extra
+func (instance NetworkUsage) MarshalJSON() ([]byte, error) {
+  type mapping NetworkUsage
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes NetworkBlock and keeps unknown fields in Extra
+func (instance *NetworkBlock) UnmarshalJSON(data []byte) error {
+  type mapping NetworkBlock
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes NetworkBlock along with unknown fields from Extra
+func (instance NetworkBlock) MarshalJSON() ([]byte, error) {
+  type mapping NetworkBlock
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes NetworkBlockOwner and keeps unknown fields in Extra
+func (instance *NetworkBlockOwner) UnmarshalJSON(data []byte) error {
+  type mapping NetworkBlockOwner
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes NetworkBlockOwner along with unknown fields from Extra
+func (instance NetworkBlockOwner) MarshalJSON() ([]byte, error) {
+  type mapping NetworkBlockOwner
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes Contact and keeps unknown fields in Extra
+func (instance *Contact) UnmarshalJSON(data []byte) error {
+  type mapping Contact
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes Contact along with unknown fields from Extra
+func (instance Contact) MarshalJSON() ([]byte, error) {
+  type mapping Contact
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes Address and keeps unknown fields in Extra
+func (instance *Address) UnmarshalJSON(data []byte) error {
+  type mapping Address
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes Address along with unknown fields from Extra
+func (instance Address) MarshalJSON() ([]byte, error) {
+  type mapping Address
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes Geolocation and keeps unknown fields in Extra
+func (instance *Geolocation) UnmarshalJSON(data []byte) error {
+  type mapping Geolocation
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes Geolocation along with unknown fields from Extra
+func (instance Geolocation) MarshalJSON() ([]byte, error) {
+  type mapping Geolocation
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes Name and keeps unknown fields in Extra
+func (instance *Name) UnmarshalJSON(data []byte) error {
+  type mapping Name
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes Name along with unknown fields from Extra
+func (instance Name) MarshalJSON() ([]byte, error) {
+  type mapping Name
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes Coordinates and keeps unknown fields in Extra
+func (instance *Coordinates) UnmarshalJSON(data []byte) error {
+  type mapping Coordinates
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes Coordinates along with unknown fields from Extra
+func (instance Coordinates) MarshalJSON() ([]byte, error) {
+  type mapping Coordinates
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
@@ -0,0 +1,425 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "encoding/json"
+  "math"
+  "strconv"
+  "strings"
+  "time"
+)
+
+
+const (
+  earthRadiusKm = 6371.0088
+  defaultMaxTravelSpeedKmh = 1000
+  defaultSightingAccuracyKm = 25
+  minTravelDuration = time.Minute
+  kmPerDegreeLatitude = 111.32
+)
+
+
+// BoundingBox maps a geographic bounding box
+type BoundingBox struct {
+  MinLatitude   float64  `json:"min_latitude"`
+  MinLongitude  float64  `json:"min_longitude"`
+  MaxLatitude   float64  `json:"max_latitude"`
+  MaxLongitude  float64  `json:"max_longitude"`
+}
+
+// LocationComparison maps the comparison of two locations
+type LocationComparison struct {
+  DistanceKm   *float64  `json:"distance_km,omitempty"`
+  SameCountry  *bool     `json:"same_country,omitempty"`
+  SameRegion   *bool     `json:"same_region,omitempty"`
+  SameCity     *bool     `json:"same_city,omitempty"`
+}
+
+// Sighting maps a location seen at a given time (eg. a login), with the accuracy radius of the location (in km, zero for default)
+type Sighting struct {
+  Coordinates  Coordinates
+  At           time.Time
+  AccuracyKm   float64
+}
+
+// GeoJSONFeatureCollection maps a GeoJSON feature collection
+type GeoJSONFeatureCollection struct {
+  Type      string            `json:"type"`
+  Features  []GeoJSONFeature  `json:"features"`
+}
+
+// GeoJSONFeature maps a GeoJSON feature with a point geometry
+type GeoJSONFeature struct {
+  Type        string                  `json:"type"`
+  Geometry    GeoJSONGeometry         `json:"geometry"`
+  Properties  map[string]interface{}  `json:"properties"`
+}
+
+// GeoJSONGeometry maps a GeoJSON point geometry
+type GeoJSONGeometry struct {
+  Type         string     `json:"type"`
+  Coordinates  []float64  `json:"coordinates"`
+}
+
+
+// String returns the string representation of BoundingBox
+func (instance BoundingBox) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of LocationComparison
+func (instance LocationComparison) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of Sighting
+func (instance Sighting) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of GeoJSONFeatureCollection
+func (instance GeoJSONFeatureCollection) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of GeoJSONFeature
+func (instance GeoJSONFeature) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of GeoJSONGeometry
+func (instance GeoJSONGeometry) String() string {
+  return Stringify(instance)
+}
+
+
+// Valid returns whether coordinates are set and within range
+func (instance Coordinates) Valid() bool {
+  if instance.Latitude == nil || instance.Longitude == nil {
+    return false
+  }
+
+  return math.Abs(float64(*instance.Latitude)) <= 90 && math.Abs(float64(*instance.Longitude)) <= 180
+}
+
+
+// DistanceTo returns the great-circle distance to other coordinates, in kilometers
+func (instance Coordinates) DistanceTo(other Coordinates) (float64, bool) {
+  if instance.Valid() == false || other.Valid() == false {
+    return 0, false
+  }
+
+  fromLatitude := degreesToRadians(float64(*instance.Latitude))
+  toLatitude := degreesToRadians(float64(*other.Latitude))
+  deltaLatitude := toLatitude - fromLatitude
+  deltaLongitude := degreesToRadians(float64(*other.Longitude) - float64(*instance.Longitude))
+
+  haversine := math.Pow(math.Sin(deltaLatitude / 2), 2) + math.Cos(fromLatitude) * math.Cos(toLatitude) * math.Pow(math.Sin(deltaLongitude / 2), 2)
+
+  return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(haversine))), true
+}
+
+
+// WithinRadius returns whether coordinates are within a radius (in kilometers) of a center
+func (instance Coordinates) WithinRadius(center Coordinates, radiusKm float64) bool {
+  distance, ok := instance.DistanceTo(center)
+
+  return ok == true && distance <= radiusKm
+}
+
+
+// NewBoundingBox returns the bounding box enclosing a radius (in kilometers) around a center
+func NewBoundingBox(center Coordinates, radiusKm float64) (BoundingBox, bool) {
+  if center.Valid() == false {
+    return BoundingBox{}, false
+  }
+
+  latitude := float64(*center.Latitude)
+  longitude := float64(*center.Longitude)
+
+  deltaLatitude := radiusKm / kmPerDegreeLatitude
+  deltaLongitude := 180.0
+
+  if cosine := math.Cos(degreesToRadians(latitude)); cosine > 0 {
+    deltaLongitude = math.Min(180, radiusKm / (kmPerDegreeLatitude * cosine))
+  }
+
+  box := BoundingBox{
+    MinLatitude: math.Max(-90, latitude - deltaLatitude),
+    MaxLatitude: math.Min(90, latitude + deltaLatitude),
+    MinLongitude: longitude - deltaLongitude,
+    MaxLongitude: longitude + deltaLongitude,
+  }
+
+  // Boxes reaching a pole, or spanning the whole globe, cover all longitudes
+  if box.MinLatitude == -90 || box.MaxLatitude == 90 || deltaLongitude >= 180 {
+    box.MinLongitude, box.MaxLongitude = -180, 180
+  }
+
+  return box, true
+}
+
+
+// Contains returns whether coordinates are within the bounding box (which may cross the antimeridian)
+func (box BoundingBox) Contains(coordinates Coordinates) bool {
+  if coordinates.Valid() == false {
+    return false
+  }
+
+  latitude := float64(*coordinates.Latitude)
+  longitude := float64(*coordinates.Longitude)
+
+  if latitude < box.MinLatitude || latitude > box.MaxLatitude {
+    return false
+  }
+
+  minLongitude := normalizeLongitude(box.MinLongitude)
+  maxLongitude := normalizeLongitude(box.MaxLongitude)
+
+  if box.MaxLongitude - box.MinLongitude >= 360 {
+    return true
+  }
+  if minLongitude <= maxLongitude {
+    return longitude >= minLongitude && longitude <= maxLongitude
+  }
+
+  return longitude >= minLongitude || longitude <= maxLongitude
+}
+
+
+// FilterWithinRadius returns the indexes of coordinates within a radius (in kilometers) of a center
+func FilterWithinRadius(coordinates []Coordinates, center Coordinates, radiusKm float64) []int {
+  var indexes []int
+
+  box, ok := NewBoundingBox(center, radiusKm)
+  if ok == false {
+    return nil
+  }
+
+  for index, current := range coordinates {
+    // The bounding box is a cheap pre-filter, before computing the exact distance
+    if box.Contains(current) == true && current.WithinRadius(center, radiusKm) == true {
+      indexes = append(indexes, index)
+    }
+  }
+
+  return indexes
+}
+
+
+// CompareLocation compares the network geolocation with the person address (or geolocation)
+func (instance Network) CompareLocation(person Person) LocationComparison {
+  var personCountry, personRegion, personCity *string
+  var personCoordinates *Coordinates
+
+  if person.Address != nil {
+    personCountry, personRegion, personCity, personCoordinates = person.Address.Country, person.Address.Region, person.Address.City, person.Address.Coordinates
+  } else if person.Geolocation != nil {
+    personCountry, personRegion, personCity, personCoordinates = person.Geolocation.Country, person.Geolocation.Region, person.Geolocation.City, person.Geolocation.Coordinates
+  }
+
+  comparison := LocationComparison{}
+
+  if instance.Geolocation == nil {
+    return comparison
+  }
+
+  geolocation := instance.Geolocation
+
+  comparison.SameCountry = equalLocationPart(geolocation.Country, personCountry)
+  comparison.SameRegion = equalLocationPart(geolocation.Region, personRegion)
+  comparison.SameCity = equalLocationPart(geolocation.City, personCity)
+
+  if geolocation.Coordinates != nil && personCoordinates != nil {
+    if distance, ok := geolocation.Coordinates.DistanceTo(*personCoordinates); ok == true {
+      comparison.DistanceKm = &distance
+    }
+  }
+
+  return comparison
+}
+
+
+// IsImpossibleTravel returns whether going from a sighting to another requires exceeding a speed (in km/h, zero for default)
+//
+// Distances within the accuracy of both sightings are ignored (eg. geolocation rounding), and sightings are considered at least a minute apart.
+func IsImpossibleTravel(from Sighting, to Sighting, maxSpeedKmh float64) bool {
+  if maxSpeedKmh <= 0 {
+    maxSpeedKmh = defaultMaxTravelSpeedKmh
+  }
+
+  distance, ok := from.Coordinates.DistanceTo(to.Coordinates)
+  if ok == false {
+    return false
+  }
+
+  distance -= sightingAccuracy(from) + sightingAccuracy(to)
+  if distance <= 0 {
+    return false
+  }
+
+  duration := to.At.Sub(from.At)
+
+  if duration < 0 {
+    duration = -duration
+  }
+  if duration < minTravelDuration {
+    duration = minTravelDuration
+  }
+
+  return distance / duration.Hours() > maxSpeedKmh
+}
+
+
+// sightingAccuracy returns the accuracy radius of a sighting (in km)
+func sightingAccuracy(sighting Sighting) float64 {
+  if sighting.AccuracyKm <= 0 {
+    return defaultSightingAccuracyKm
+  }
+
+  return sighting.AccuracyKm
+}
+
+
+// NewGeoJSONFeatureCollection returns a GeoJSON feature collection
+func NewGeoJSONFeatureCollection(features ...GeoJSONFeature) GeoJSONFeatureCollection {
+  if features == nil {
+    features = []GeoJSONFeature{}
+  }
+
+  return GeoJSONFeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+
+// GeoJSONFeature exports the person as a GeoJSON feature, with selected properties (as JSON paths, eg. 'name.full')
+func (instance Person) GeoJSONFeature(properties ...string) (GeoJSONFeature, bool) {
+  coordinates := (*Coordinates)(nil)
+
+  if instance.Address != nil && instance.Address.Coordinates != nil && instance.Address.Coordinates.Valid() == true {
+    coordinates = instance.Address.Coordinates
+  } else if instance.Geolocation != nil {
+    coordinates = instance.Geolocation.Coordinates
+  }
+
+  return newGeoJSONFeature(instance, coordinates, properties)
+}
+
+// GeoJSONFeature exports the company as a GeoJSON feature, with selected properties (as JSON paths, eg. 'address.city')
+func (instance Company) GeoJSONFeature(properties ...string) (GeoJSONFeature, bool) {
+  coordinates := (*Coordinates)(nil)
+
+  if instance.Address != nil {
+    coordinates = instance.Address.Coordinates
+  }
+
+  return newGeoJSONFeature(instance, coordinates, properties)
+}
+
+// GeoJSONFeature exports the network as a GeoJSON feature, with selected properties (as JSON paths, eg. 'ip')
+func (instance Network) GeoJSONFeature(properties ...string) (GeoJSONFeature, bool) {
+  coordinates := (*Coordinates)(nil)
+
+  if instance.Geolocation != nil {
+    coordinates = instance.Geolocation.Coordinates
+  }
+
+  return newGeoJSONFeature(instance, coordinates, properties)
+}
+
+
+// newGeoJSONFeature builds a GeoJSON point feature for a record
+func newGeoJSONFeature(record interface{}, coordinates *Coordinates, properties []string) (GeoJSONFeature, bool) {
+  if coordinates == nil || coordinates.Valid() == false {
+    return GeoJSONFeature{}, false
+  }
+
+  feature := GeoJSONFeature{
+    Type: "Feature",
+    Geometry: GeoJSONGeometry{
+      Type: "Point",
+      Coordinates: []float64{exactFloat64(*coordinates.Longitude), exactFloat64(*coordinates.Latitude)},
+    },
+    Properties: make(map[string]interface{}),
+  }
+
+  if len(properties) == 0 {
+    return feature, true
+  }
+
+  var document interface{}
+
+  if data, err := json.Marshal(record); err == nil {
+    json.Unmarshal(data, &document)
+  }
+
+  for _, property := range properties {
+    if value, ok := lookupJSONPath(document, property); ok == true {
+      feature.Properties[property] = value
+    }
+  }
+
+  return feature, true
+}
+
+
+// lookupJSONPath resolves a dotted path (eg. 'name.full') in a decoded JSON document
+func lookupJSONPath(document interface{}, path string) (interface{}, bool) {
+  current := document
+
+  for _, key := range strings.Split(path, ".") {
+    object, ok := current.(map[string]interface{})
+    if ok == false {
+      return nil, false
+    }
+
+    if current, ok = object[key]; ok == false {
+      return nil, false
+    }
+  }
+
+  return current, true
+}
+
+
+// equalLocationPart compares two optional location parts (case-insensitive), if both are known
+func equalLocationPart(first *string, second *string) *bool {
+  if first == nil || second == nil || *first == "" || *second == "" {
+    return nil
+  }
+
+  equal := strings.EqualFold(strings.TrimSpace(*first), strings.TrimSpace(*second))
+
+  return &equal
+}
+
+
+// normalizeLongitude wraps a longitude in the [-180, 180] range
+func normalizeLongitude(longitude float64) float64 {
+  for longitude > 180 {
+    longitude -= 360
+  }
+  for longitude < -180 {
+    longitude += 360
+  }
+
+  return longitude
+}
+
+
+// exactFloat64 converts a float32 to the float64 with the same shortest decimal representation
+func exactFloat64(value float32) float64 {
+  converted, _ := strconv.ParseFloat(strconv.FormatFloat(float64(value), 'f', -1, 32), 64)
+
+  return converted
+}
+
+
+// degreesToRadians converts degrees to radians
+func degreesToRadians(degrees float64) float64 {
+  return degrees * math.Pi / 180
+}
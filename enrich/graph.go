@@ -0,0 +1,885 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "fmt"
+  "net"
+  "net/url"
+  "sort"
+  "strings"
+  "sync"
+  "unicode"
+)
+
+
+// GraphNodeKind maps the kind of an identity graph node
+type GraphNodeKind string
+
+// GraphEdgeKind maps the kind of a link between identity graph nodes
+type GraphEdgeKind string
+
+// GraphNode maps an entity of the identity graph, with all its known identifiers and its merged record
+type GraphNode struct {
+  ID           string         `json:"id"`
+  Kind         GraphNodeKind  `json:"kind"`
+  Identifiers  []string       `json:"identifiers"`
+  Person       *Person        `json:"person,omitempty"`
+  Company      *Company       `json:"company,omitempty"`
+  Network      *Network       `json:"network,omitempty"`
+}
+
+// GraphEdge maps a link between two identity graph nodes
+type GraphEdge struct {
+  From  string         `json:"from"`
+  Kind  GraphEdgeKind  `json:"kind"`
+  To    string         `json:"to"`
+}
+
+// GraphExport maps an export of the identity graph
+type GraphExport struct {
+  Nodes  []GraphNode  `json:"nodes"`
+  Edges  []GraphEdge  `json:"edges"`
+}
+
+// IdentityGraph links persons, companies and networks seen across enrichments, by stable identifiers
+//
+// Identifiers are prefixed by their type: 'id:', 'domain:', 'email:', 'social:', 'ip:', 'name:' (names of companies having no ID nor domain, without legal suffixes) and 'owner:' (organizations owning network blocks, which are kept apart from other companies as they often are ISPs). Nodes sharing an identifier are merged together, unless their IDs or domains conflict.
+type IdentityGraph struct {
+  lock   sync.RWMutex
+  nodes  map[string]*GraphNode
+  index     map[GraphNodeKind]map[string]string
+  edges     map[GraphEdge]bool
+  outgoing  map[string]map[GraphEdge]bool
+  incoming  map[string]map[GraphEdge]bool
+}
+
+
+// Graph node kinds
+const (
+  GraphNodePerson GraphNodeKind = "person"
+  GraphNodeCompany GraphNodeKind = "company"
+  GraphNodeNetwork GraphNodeKind = "network"
+)
+
+// Graph edge kinds
+const (
+  GraphEdgeWorksAt GraphEdgeKind = "works_at"
+  GraphEdgeBelongsTo GraphEdgeKind = "belongs_to"
+)
+
+// companyLegalSuffixes lists legal form suffixes, ignored when comparing company names
+var companyLegalSuffixes = []string{
+  "inc", "incorporated", "llc", "llp", "lp", "ltd", "limited", "corp", "corporation", "co", "company", "plc",
+  "sa", "sas", "sasu", "sarl", "eurl", "gmbh", "ag", "bv", "nv", "srl", "spa", "oy", "ab", "as", "pty",
+}
+
+// graphRecordPolicy merges records of a node, preferring the latest ingested values and unioning slices
+var graphRecordPolicy = &MergePolicy{Default: MergeUnion}
+
+
+// String returns the string representation of GraphNode
+func (instance GraphNode) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of GraphEdge
+func (instance GraphEdge) String() string {
+  return Stringify(instance)
+}
+
+
+// NewIdentityGraph returns an empty identity graph
+func NewIdentityGraph() *IdentityGraph {
+  return &IdentityGraph{
+    nodes: make(map[string]*GraphNode),
+    index: make(map[GraphNodeKind]map[string]string),
+    edges: make(map[GraphEdge]bool),
+    outgoing: make(map[string]map[GraphEdge]bool),
+    incoming: make(map[string]map[GraphEdge]bool),
+  }
+}
+
+
+// Ingest adds an enrichment result (eg. *EnrichPersonData) or a record (eg. *Company) to the graph
+func (graph *IdentityGraph) Ingest(data interface{}) error {
+  graph.lock.Lock()
+  defer graph.lock.Unlock()
+
+  switch value := data.(type) {
+    case *EnrichPersonData:
+      if value != nil {
+        graph.ingestPersonData(value)
+      }
+
+    case EnrichPersonData:
+      graph.ingestPersonData(&value)
+
+    case *EnrichCompanyData:
+      if value != nil && value.Company != nil {
+        graph.ingestCompany(value.Company)
+      }
+
+    case EnrichCompanyData:
+      if value.Company != nil {
+        graph.ingestCompany(value.Company)
+      }
+
+    case *EnrichNetworkData:
+      if value != nil {
+        graph.ingestNetworkData(value)
+      }
+
+    case EnrichNetworkData:
+      graph.ingestNetworkData(&value)
+
+    case *Person:
+      if value != nil {
+        graph.ingestPerson(value)
+      }
+
+    case *Company:
+      if value != nil {
+        graph.ingestCompany(value)
+      }
+
+    case *Network:
+      if value != nil {
+        graph.ingestNetworkData(&EnrichNetworkData{Network: value})
+      }
+
+    default:
+      return fmt.Errorf("cannot ingest %T in identity graph", data)
+  }
+
+  return nil
+}
+
+
+// Lookup returns the node having an identifier (eg. 'domain:crisp.chat' or 'email:valerian@crisp.chat')
+func (graph *IdentityGraph) Lookup(kind GraphNodeKind, identifier string) (GraphNode, bool) {
+  graph.lock.RLock()
+  defer graph.lock.RUnlock()
+
+  if node := graph.lookup(kind, normalizeGraphIdentifier(identifier)); node != nil {
+    return node.snapshot(), true
+  }
+
+  return GraphNode{}, false
+}
+
+
+// EmployeesOf returns all known employees of a company domain
+func (graph *IdentityGraph) EmployeesOf(domain string) []GraphNode {
+  graph.lock.RLock()
+  defer graph.lock.RUnlock()
+
+  company := graph.lookup(GraphNodeCompany, graphIdentifier("domain", normalizeDomain(domain)))
+
+  if company == nil {
+    return nil
+  }
+
+  return graph.linked(GraphEdgeWorksAt, "", company.ID)
+}
+
+
+// EmployersOf returns all known employers of a person, by email
+func (graph *IdentityGraph) EmployersOf(email string) []GraphNode {
+  graph.lock.RLock()
+  defer graph.lock.RUnlock()
+
+  person := graph.lookup(GraphNodePerson, graphIdentifier("email", strings.ToLower(strings.TrimSpace(email))))
+
+  if person == nil {
+    return nil
+  }
+
+  return graph.linked(GraphEdgeWorksAt, person.ID, "")
+}
+
+
+// CompaniesInBlock returns the companies that networks from an IP block (eg. '178.62.0.0/16', or a single IP) belong to
+func (graph *IdentityGraph) CompaniesInBlock(block string) ([]GraphNode, error) {
+  blockNetwork, err := parseGraphBlock(block)
+  if err != nil {
+    return nil, err
+  }
+
+  graph.lock.RLock()
+  defer graph.lock.RUnlock()
+
+  var companies []GraphNode
+
+  seen := make(map[string]bool)
+
+  for _, node := range graph.nodes {
+    if node.Kind != GraphNodeNetwork || node.Network == nil || graphNetworkInBlock(node.Network, blockNetwork) == false {
+      continue
+    }
+
+    for edge := range graph.outgoing[node.ID] {
+      if edge.Kind == GraphEdgeBelongsTo && seen[edge.To] == false {
+        seen[edge.To] = true
+
+        companies = append(companies, graph.nodes[edge.To].snapshot())
+      }
+    }
+  }
+
+  sortGraphNodes(companies)
+
+  return companies, nil
+}
+
+
+// Export returns all nodes and edges of the graph, sorted for a stable output (eg. to be marshalled to JSON)
+func (graph *IdentityGraph) Export() GraphExport {
+  graph.lock.RLock()
+  defer graph.lock.RUnlock()
+
+  export := GraphExport{Nodes: make([]GraphNode, 0, len(graph.nodes)), Edges: make([]GraphEdge, 0, len(graph.edges))}
+
+  for _, node := range graph.nodes {
+    export.Nodes = append(export.Nodes, node.snapshot())
+  }
+
+  for edge := range graph.edges {
+    export.Edges = append(export.Edges, edge)
+  }
+
+  sortGraphNodes(export.Nodes)
+
+  sort.Slice(export.Edges, func(i, j int) bool {
+    first, second := export.Edges[i], export.Edges[j]
+
+    if first.From != second.From {
+      return first.From < second.From
+    }
+    if first.Kind != second.Kind {
+      return first.Kind < second.Kind
+    }
+
+    return first.To < second.To
+  })
+
+  return export
+}
+
+
+// ingestPersonData ingests a person and its companies, linking them through employments
+func (graph *IdentityGraph) ingestPersonData(data *EnrichPersonData) {
+  var person *GraphNode
+
+  if data.Person != nil {
+    person = graph.ingestPerson(data.Person)
+  }
+
+  if data.Companies != nil {
+    for i := range *data.Companies {
+      company := graph.ingestCompany(&(*data.Companies)[i])
+
+      if person != nil && company != nil {
+        graph.link(person.ID, GraphEdgeWorksAt, company.ID)
+      }
+    }
+  }
+}
+
+
+// ingestPerson ingests a person, and the companies of its employments
+func (graph *IdentityGraph) ingestPerson(person *Person) *GraphNode {
+  identifiers := personIdentifiers(person.ID, person.Contact, person.SocialProfiles())
+
+  node := graph.resolve(GraphNodePerson, identifiers)
+
+  if node == nil {
+    return nil
+  }
+
+  node.Person = mergeGraphPerson(node.Person, person)
+
+  if person.Employments != nil {
+    for _, employment := range *person.Employments {
+      company := graph.resolve(GraphNodeCompany, companyIdentifiers(employment.ID, employment.Domain, employment.Name, nil))
+
+      if company != nil {
+        if company.Company == nil {
+          company.Company = &Company{ID: employment.ID, Name: employment.Name}
+
+          if employment.Domain != nil {
+            company.Company.Contact = &Contact{Domain: employment.Domain}
+          }
+        }
+
+        graph.link(node.ID, GraphEdgeWorksAt, company.ID)
+      }
+    }
+  }
+
+  return node
+}
+
+
+// ingestCompany ingests a company, and its known employees
+func (graph *IdentityGraph) ingestCompany(company *Company) *GraphNode {
+  var domain *string
+
+  if company.Contact != nil {
+    domain = company.Contact.Domain
+  }
+
+  identifiers := companyIdentifiers(company.ID, domain, company.Name, company)
+
+  node := graph.resolve(GraphNodeCompany, identifiers)
+
+  if node == nil {
+    return nil
+  }
+
+  node.Company = mergeGraphCompany(node.Company, company)
+
+  if company.Employees != nil && company.Employees.Persons != nil {
+    for _, employee := range *company.Employees.Persons {
+      var profiles []SocialProfile
+
+      if employee.Contact != nil {
+        profiles = employee.Contact.SocialProfiles()
+      }
+
+      person := graph.resolve(GraphNodePerson, personIdentifiers(employee.ID, employee.Contact, profiles))
+
+      if person != nil {
+        if person.Person == nil {
+          person.Person = &Person{ID: employee.ID, Name: employee.Name, Contact: employee.Contact}
+        }
+
+        graph.link(person.ID, GraphEdgeWorksAt, node.ID)
+      }
+    }
+  }
+
+  return node
+}
+
+
+// ingestNetworkData ingests a network, and the companies it belongs to (including its block owner organization)
+func (graph *IdentityGraph) ingestNetworkData(data *EnrichNetworkData) {
+  var network *GraphNode
+
+  if data.Network != nil {
+    var identifiers []string
+
+    identifiers = appendGraphIdentifier(identifiers, "id", data.Network.ID)
+
+    if data.Network.IP != nil {
+      if ip := net.ParseIP(strings.TrimSpace(*data.Network.IP)); ip != nil {
+        identifiers = append(identifiers, graphIdentifier("ip", ip.String()))
+      }
+    }
+
+    network = graph.resolve(GraphNodeNetwork, identifiers)
+
+    if network != nil {
+      network.Network = mergeGraphNetwork(network.Network, data.Network)
+
+      if data.Network.Block != nil && data.Network.Block.Owner != nil && data.Network.Block.Owner.Organization != nil {
+        if owner := graph.ingestBlockOwner(data.Network.Block.Owner); owner != nil {
+          graph.link(network.ID, GraphEdgeBelongsTo, owner.ID)
+        }
+      }
+    }
+  }
+
+  if data.Company != nil {
+    company := graph.ingestCompany(data.Company)
+
+    if network != nil && company != nil {
+      graph.link(network.ID, GraphEdgeBelongsTo, company.ID)
+    }
+  }
+}
+
+
+// ingestBlockOwner ingests the organization owning a network block, apart from other companies
+func (graph *IdentityGraph) ingestBlockOwner(owner *NetworkBlockOwner) *GraphNode {
+  name := normalizeCompanyName(stringValue(owner.Organization))
+
+  if name == "" {
+    return nil
+  }
+
+  node := graph.resolve(GraphNodeCompany, []string{graphIdentifier("owner", name)})
+
+  if node.Company == nil {
+    node.Company = &Company{Name: owner.Organization, Contact: owner.Contact, Address: owner.Address}
+  }
+
+  return node
+}
+
+
+// resolve returns the node having any of the identifiers (merging nodes if several match), creating it if needed
+//
+// Nodes whose IDs or domains conflict are never merged: identifiers they share stay with the node first having them.
+func (graph *IdentityGraph) resolve(kind GraphNodeKind, identifiers []string) *GraphNode {
+  if len(identifiers) == 0 {
+    return nil
+  }
+
+  if graph.index[kind] == nil {
+    graph.index[kind] = make(map[string]string)
+  }
+
+  var node *GraphNode
+  var taken []string
+
+  for _, identifier := range identifiers {
+    id, ok := graph.index[kind][identifier]
+
+    if ok == false || (node != nil && id == node.ID) {
+      continue
+    }
+
+    candidate, resolved := graph.nodes[id], identifiers
+
+    if node != nil {
+      resolved = append(append([]string{}, node.Identifiers...), identifiers...)
+    }
+
+    if graphIdentifiersConflict(candidate.Identifiers, resolved) == true {
+      taken = append(taken, identifier)
+    } else if node == nil {
+      node = candidate
+    } else {
+      graph.absorb(node, candidate)
+    }
+  }
+
+  if node == nil {
+    node = &GraphNode{ID: graph.newNodeID(kind, identifiers[0]), Kind: kind}
+    graph.nodes[node.ID] = node
+  }
+
+  for _, identifier := range identifiers {
+    if containsString(taken, identifier) == true {
+      continue
+    }
+
+    if containsString(node.Identifiers, identifier) == false {
+      node.Identifiers = append(node.Identifiers, identifier)
+    }
+
+    graph.index[kind][identifier] = node.ID
+  }
+
+  sort.Strings(node.Identifiers)
+
+  return node
+}
+
+
+// absorb merges a node into another one, moving its identifiers, record and edges
+func (graph *IdentityGraph) absorb(node *GraphNode, other *GraphNode) {
+  for _, identifier := range other.Identifiers {
+    if containsString(node.Identifiers, identifier) == false {
+      node.Identifiers = append(node.Identifiers, identifier)
+    }
+
+    graph.index[node.Kind][identifier] = node.ID
+  }
+
+  if other.Person != nil {
+    node.Person = mergeGraphPerson(other.Person, node.Person)
+  }
+  if other.Company != nil {
+    node.Company = mergeGraphCompany(other.Company, node.Company)
+  }
+  if other.Network != nil {
+    node.Network = mergeGraphNetwork(other.Network, node.Network)
+  }
+
+  // Edges are collected first, as they get removed from the adjacency maps while moved
+  var edges []GraphEdge
+
+  for edge := range graph.outgoing[other.ID] {
+    edges = append(edges, edge)
+  }
+  for edge := range graph.incoming[other.ID] {
+    if edge.From != other.ID {
+      edges = append(edges, edge)
+    }
+  }
+
+  for _, edge := range edges {
+    graph.unlink(edge)
+
+    if edge.From == other.ID {
+      edge.From = node.ID
+    }
+    if edge.To == other.ID {
+      edge.To = node.ID
+    }
+
+    graph.link(edge.From, edge.Kind, edge.To)
+  }
+
+  delete(graph.nodes, other.ID)
+}
+
+
+// newNodeID returns an unused node ID, from the first identifier of the node
+func (graph *IdentityGraph) newNodeID(kind GraphNodeKind, identifier string) string {
+  id := string(kind) + ":" + identifier
+
+  for i := 2; graph.nodes[id] != nil; i++ {
+    id = fmt.Sprintf("%s:%s#%d", kind, identifier, i)
+  }
+
+  return id
+}
+
+
+// link adds an edge between two nodes
+func (graph *IdentityGraph) link(from string, kind GraphEdgeKind, to string) {
+  if from == to {
+    return
+  }
+
+  edge := GraphEdge{From: from, Kind: kind, To: to}
+
+  graph.edges[edge] = true
+
+  if graph.outgoing[from] == nil {
+    graph.outgoing[from] = make(map[GraphEdge]bool)
+  }
+  if graph.incoming[to] == nil {
+    graph.incoming[to] = make(map[GraphEdge]bool)
+  }
+
+  graph.outgoing[from][edge] = true
+  graph.incoming[to][edge] = true
+}
+
+
+// unlink removes an edge between two nodes
+func (graph *IdentityGraph) unlink(edge GraphEdge) {
+  delete(graph.edges, edge)
+  delete(graph.outgoing[edge.From], edge)
+  delete(graph.incoming[edge.To], edge)
+
+  if len(graph.outgoing[edge.From]) == 0 {
+    delete(graph.outgoing, edge.From)
+  }
+  if len(graph.incoming[edge.To]) == 0 {
+    delete(graph.incoming, edge.To)
+  }
+}
+
+
+// lookup returns the node having an identifier, if any
+func (graph *IdentityGraph) lookup(kind GraphNodeKind, identifier string) *GraphNode {
+  if id, ok := graph.index[kind][identifier]; ok == true {
+    return graph.nodes[id]
+  }
+
+  return nil
+}
+
+
+// linked returns the nodes linked to a node, either as source (from) or as target (to)
+func (graph *IdentityGraph) linked(kind GraphEdgeKind, from string, to string) []GraphNode {
+  var nodes []GraphNode
+
+  if from != "" {
+    for edge := range graph.outgoing[from] {
+      if edge.Kind == kind {
+        nodes = append(nodes, graph.nodes[edge.To].snapshot())
+      }
+    }
+  } else {
+    for edge := range graph.incoming[to] {
+      if edge.Kind == kind {
+        nodes = append(nodes, graph.nodes[edge.From].snapshot())
+      }
+    }
+  }
+
+  sortGraphNodes(nodes)
+
+  return nodes
+}
+
+
+// personIdentifiers returns the identifiers of a person
+func personIdentifiers(id *string, contact *Contact, profiles []SocialProfile) []string {
+  var identifiers []string
+
+  identifiers = appendGraphIdentifier(identifiers, "id", id)
+
+  if contact != nil && contact.Emails != nil {
+    for _, email := range *contact.Emails {
+      if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+        identifiers = append(identifiers, graphIdentifier("email", email))
+      }
+    }
+  }
+
+  for _, profile := range profiles {
+    if profile.Handle != "" {
+      identifiers = append(identifiers, graphIdentifier("social", profile.Network + ":" + strings.ToLower(profile.Handle)))
+    } else if profile.ID != "" {
+      identifiers = append(identifiers, graphIdentifier("social", profile.Network + ":id:" + profile.ID))
+    }
+  }
+
+  return identifiers
+}
+
+
+// companyIdentifiers returns the identifiers of a company (its website host also counts as a domain)
+func companyIdentifiers(id *string, domain *string, name *string, company *Company) []string {
+  var identifiers []string
+
+  identifiers = appendGraphIdentifier(identifiers, "id", id)
+
+  if domain != nil {
+    if normalized := normalizeDomain(*domain); normalized != "" {
+      identifiers = append(identifiers, graphIdentifier("domain", normalized))
+    }
+  }
+
+  if company != nil && company.Contact != nil && company.Contact.Website != nil {
+    if normalized := normalizeDomain(*company.Contact.Website); normalized != "" && containsString(identifiers, graphIdentifier("domain", normalized)) == false {
+      identifiers = append(identifiers, graphIdentifier("domain", normalized))
+    }
+  }
+
+  if company != nil {
+    for _, profile := range company.SocialProfiles() {
+      if profile.Handle != "" {
+        identifiers = append(identifiers, graphIdentifier("social", profile.Network + ":" + strings.ToLower(profile.Handle)))
+      }
+    }
+  }
+
+  // Names are not unique, thus only identify companies having no ID nor domain
+  if name != nil && len(identifiers) == len(filterGraphIdentifiers(identifiers, "social")) {
+    if normalized := normalizeCompanyName(*name); normalized != "" {
+      identifiers = append(identifiers, graphIdentifier("name", normalized))
+    }
+  }
+
+  return identifiers
+}
+
+
+// graphIdentifiersConflict returns whether two identifier sets belong to different entities (ie. they both have IDs or domains, but none in common)
+func graphIdentifiersConflict(first []string, second []string) bool {
+  for _, kind := range []string{"id", "domain"} {
+    firstValues, secondValues := filterGraphIdentifiers(first, kind), filterGraphIdentifiers(second, kind)
+
+    if len(firstValues) == 0 || len(secondValues) == 0 {
+      continue
+    }
+
+    shared := false
+
+    for _, value := range firstValues {
+      if containsString(secondValues, value) == true {
+        shared = true
+        break
+      }
+    }
+
+    if shared == false {
+      return true
+    }
+  }
+
+  return false
+}
+
+
+// filterGraphIdentifiers returns the identifiers of a type
+func filterGraphIdentifiers(identifiers []string, kind string) []string {
+  var filtered []string
+
+  for _, identifier := range identifiers {
+    if strings.HasPrefix(identifier, kind + ":") == true {
+      filtered = append(filtered, identifier)
+    }
+  }
+
+  return filtered
+}
+
+
+// appendGraphIdentifier appends an identifier, if its value is set
+func appendGraphIdentifier(identifiers []string, kind string, value *string) []string {
+  if value == nil || strings.TrimSpace(*value) == "" {
+    return identifiers
+  }
+
+  return append(identifiers, graphIdentifier(kind, strings.TrimSpace(*value)))
+}
+
+
+// graphIdentifier returns a typed identifier (eg. 'domain:crisp.chat')
+func graphIdentifier(kind string, value string) string {
+  return kind + ":" + value
+}
+
+
+// normalizeGraphIdentifier normalizes a typed identifier, as stored in the graph
+func normalizeGraphIdentifier(identifier string) string {
+  parts := strings.SplitN(strings.TrimSpace(identifier), ":", 2)
+
+  if len(parts) != 2 {
+    return identifier
+  }
+
+  kind, value := strings.ToLower(parts[0]), strings.TrimSpace(parts[1])
+
+  switch kind {
+    case "domain":
+      value = normalizeDomain(value)
+
+    case "email", "social":
+      value = strings.ToLower(value)
+
+    case "name", "owner":
+      value = normalizeCompanyName(value)
+
+    case "ip":
+      if ip := net.ParseIP(value); ip != nil {
+        value = ip.String()
+      }
+  }
+
+  return graphIdentifier(kind, value)
+}
+
+
+// normalizeDomain normalizes a domain or an URL to a bare lowercase domain (eg. 'https://www.Crisp.chat/' to 'crisp.chat')
+func normalizeDomain(value string) string {
+  value = strings.ToLower(strings.TrimSpace(value))
+
+  if strings.Contains(value, "://") == true {
+    if parsed, err := url.Parse(value); err == nil {
+      value = parsed.Hostname()
+    }
+  } else if index := strings.IndexAny(value, "/?#"); index >= 0 {
+    value = value[:index]
+  }
+
+  return strings.TrimPrefix(strings.TrimSuffix(value, "."), "www.")
+}
+
+
+// normalizeCompanyName normalizes a company name for comparison (eg. 'Crisp IM, Inc.' to 'crisp im')
+func normalizeCompanyName(name string) string {
+  words := strings.FieldsFunc(strings.ToLower(name), func(character rune) bool {
+    return unicode.IsLetter(character) == false && unicode.IsDigit(character) == false
+  })
+
+  for len(words) > 1 && containsString(companyLegalSuffixes, words[len(words) - 1]) == true {
+    words = words[:len(words) - 1]
+  }
+
+  return strings.Join(words, " ")
+}
+
+
+// parseGraphBlock parses an IP block (CIDR), or a single IP
+func parseGraphBlock(block string) (*net.IPNet, error) {
+  block = strings.TrimSpace(block)
+
+  if _, network, err := net.ParseCIDR(block); err == nil {
+    return network, nil
+  }
+
+  ip := net.ParseIP(block)
+
+  if ip == nil {
+    return nil, fmt.Errorf("invalid IP block: %q", block)
+  }
+
+  if ip.To4() != nil {
+    return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}, nil
+  }
+
+  return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+
+// graphNetworkInBlock returns whether a network (its IP, or its own block) falls within an IP block
+func graphNetworkInBlock(network *Network, block *net.IPNet) bool {
+  if network.IP != nil {
+    if ip := net.ParseIP(strings.TrimSpace(*network.IP)); ip != nil && block.Contains(ip) == true {
+      return true
+    }
+  }
+
+  if network.Block != nil && network.Block.Range != nil {
+    if _, networkBlock, err := net.ParseCIDR(strings.TrimSpace(*network.Block.Range)); err == nil {
+      blockSize, _ := block.Mask.Size()
+      networkSize, _ := networkBlock.Mask.Size()
+
+      return networkSize >= blockSize && block.Contains(networkBlock.IP) == true
+    }
+  }
+
+  return false
+}
+
+
+// mergeGraphPerson merges a person into a known one (the latest values win)
+func mergeGraphPerson(known *Person, latest *Person) *Person {
+  merged := &Person{}
+
+  graphRecordPolicy.Merge(merged, MergeSource{Data: latest}, MergeSource{Data: known})
+
+  return merged
+}
+
+
+// mergeGraphCompany merges a company into a known one (the latest values win)
+func mergeGraphCompany(known *Company, latest *Company) *Company {
+  merged := &Company{}
+
+  graphRecordPolicy.Merge(merged, MergeSource{Data: latest}, MergeSource{Data: known})
+
+  return merged
+}
+
+
+// mergeGraphNetwork merges a network into a known one (the latest values win)
+func mergeGraphNetwork(known *Network, latest *Network) *Network {
+  merged := &Network{}
+
+  graphRecordPolicy.Merge(merged, MergeSource{Data: latest}, MergeSource{Data: known})
+
+  return merged
+}
+
+
+// snapshot returns a copy of the node, not sharing its identifiers with the graph
+func (node *GraphNode) snapshot() GraphNode {
+  snapshot := *node
+  snapshot.Identifiers = append([]string{}, node.Identifiers...)
+
+  return snapshot
+}
+
+
+// sortGraphNodes sorts nodes by ID
+func sortGraphNodes(nodes []GraphNode) {
+  sort.Slice(nodes, func(i, j int) bool {
+    return nodes[i].ID < nodes[j].ID
+  })
+}
@@ -0,0 +1,363 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "encoding/json"
+  "strconv"
+  "strings"
+)
+
+
+const jsonLDContext = "https://schema.org"
+
+// jsonLDOrganizationTypes maps company kinds to their schema.org organization type
+var jsonLDOrganizationTypes = map[CompanyKind]string{
+  CompanyKindEducation: "EducationalOrganization",
+  CompanyKindGovernment: "GovernmentOrganization",
+  CompanyKindNonProfit: "NGO",
+}
+
+
+// JSONLDOrganization maps a schema.org Organization
+//
+// Fields are declared in output order, so that the JSON-LD key order is stable.
+type JSONLDOrganization struct {
+  Context            string                    `json:"@context,omitempty"`
+  Type               string                    `json:"@type"`
+  Name               string                    `json:"name,omitempty"`
+  LegalName          string                    `json:"legalName,omitempty"`
+  Description        string                    `json:"description,omitempty"`
+  URL                string                    `json:"url,omitempty"`
+  Logo               string                    `json:"logo,omitempty"`
+  FoundingDate       string                    `json:"foundingDate,omitempty"`
+  Email              string                    `json:"email,omitempty"`
+  Telephone          string                    `json:"telephone,omitempty"`
+  Address            *JSONLDPostalAddress      `json:"address,omitempty"`
+  NumberOfEmployees  *JSONLDQuantitativeValue  `json:"numberOfEmployees,omitempty"`
+  SameAs             []string                  `json:"sameAs,omitempty"`
+}
+
+// JSONLDPerson maps a schema.org Person
+type JSONLDPerson struct {
+  Context        string                `json:"@context,omitempty"`
+  Type           string                `json:"@type"`
+  Name           string                `json:"name,omitempty"`
+  GivenName      string                `json:"givenName,omitempty"`
+  FamilyName     string                `json:"familyName,omitempty"`
+  Gender         string                `json:"gender,omitempty"`
+  Image          string                `json:"image,omitempty"`
+  Description    string                `json:"description,omitempty"`
+  JobTitle       string                `json:"jobTitle,omitempty"`
+  Email          string                `json:"email,omitempty"`
+  Telephone      string                `json:"telephone,omitempty"`
+  URL            string                `json:"url,omitempty"`
+  Address        *JSONLDPostalAddress  `json:"address,omitempty"`
+  KnowsLanguage  []string              `json:"knowsLanguage,omitempty"`
+  WorksFor       *JSONLDOrganization   `json:"worksFor,omitempty"`
+  SameAs         []string              `json:"sameAs,omitempty"`
+}
+
+// JSONLDPlace maps a schema.org Place
+type JSONLDPlace struct {
+  Context     string                 `json:"@context,omitempty"`
+  Type        string                 `json:"@type"`
+  Identifier  string                 `json:"identifier,omitempty"`
+  Name        string                 `json:"name,omitempty"`
+  Address     *JSONLDPostalAddress   `json:"address,omitempty"`
+  Geo         *JSONLDGeoCoordinates  `json:"geo,omitempty"`
+}
+
+// JSONLDPostalAddress maps a schema.org PostalAddress
+type JSONLDPostalAddress struct {
+  Type             string  `json:"@type"`
+  StreetAddress    string  `json:"streetAddress,omitempty"`
+  AddressLocality  string  `json:"addressLocality,omitempty"`
+  AddressRegion    string  `json:"addressRegion,omitempty"`
+  PostalCode       string  `json:"postalCode,omitempty"`
+  AddressCountry   string  `json:"addressCountry,omitempty"`
+}
+
+// JSONLDGeoCoordinates maps schema.org GeoCoordinates
+type JSONLDGeoCoordinates struct {
+  Type       string   `json:"@type"`
+  Latitude   float64  `json:"latitude"`
+  Longitude  float64  `json:"longitude"`
+}
+
+// JSONLDQuantitativeValue maps a schema.org QuantitativeValue (either exact, or as a range)
+type JSONLDQuantitativeValue struct {
+  Type      string   `json:"@type"`
+  Value     *uint32  `json:"value,omitempty"`
+  MinValue  *uint32  `json:"minValue,omitempty"`
+  MaxValue  *uint32  `json:"maxValue,omitempty"`
+}
+
+
+// String returns the string representation of JSONLDOrganization
+func (instance JSONLDOrganization) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of JSONLDPerson
+func (instance JSONLDPerson) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of JSONLDPlace
+func (instance JSONLDPlace) String() string {
+  return Stringify(instance)
+}
+
+
+// JSONLD returns the company as a schema.org Organization
+func (instance Company) JSONLD() JSONLDOrganization {
+  organization := instance.jsonLDOrganization()
+  organization.Context = jsonLDContext
+
+  return organization
+}
+
+
+// JSONLD returns the person as a schema.org Person
+//
+// The person works for the company if provided, otherwise for its current employment (ie. the first one).
+func (instance Person) JSONLD(company *Company) JSONLDPerson {
+  person := JSONLDPerson{
+    Context: jsonLDContext,
+    Type: "Person",
+    Image: stringValue(instance.Avatar),
+    Description: stringValue(instance.Description),
+    Address: newJSONLDPostalAddress(instance.Address),
+  }
+
+  if instance.Name != nil {
+    person.GivenName = stringValue(instance.Name.First)
+    person.FamilyName = stringValue(instance.Name.Last)
+
+    if instance.Name.Full != nil {
+      person.Name = *instance.Name.Full
+    } else {
+      person.Name = strings.TrimSpace(person.GivenName + " " + person.FamilyName)
+    }
+  }
+
+  if instance.Gender != nil {
+    switch ParseGender(string(*instance.Gender)) {
+      case GenderMale:
+        person.Gender = "https://schema.org/Male"
+
+      case GenderFemale:
+        person.Gender = "https://schema.org/Female"
+    }
+  }
+
+  var region string
+
+  if instance.Address != nil && instance.Address.Country != nil {
+    region = *instance.Address.Country
+  }
+
+  person.Email, person.Telephone, person.URL = jsonLDContact(instance.Contact, region)
+
+  for _, locale := range instance.ParsedLocales() {
+    person.KnowsLanguage = append(person.KnowsLanguage, locale.String())
+  }
+
+  for _, profile := range instance.SocialProfiles() {
+    person.SameAs = append(person.SameAs, profile.URL)
+  }
+
+  if instance.Employments != nil && len(*instance.Employments) > 0 {
+    employment := (*instance.Employments)[0]
+
+    person.JobTitle = stringValue(employment.Title)
+
+    if company == nil {
+      organization := JSONLDOrganization{Type: "Organization", Name: stringValue(employment.Name)}
+
+      if employment.Domain != nil && *employment.Domain != "" {
+        organization.URL = "https://" + *employment.Domain
+      }
+
+      if organization.Name != "" || organization.URL != "" {
+        person.WorksFor = &organization
+      }
+    }
+  }
+
+  if company != nil {
+    organization := company.jsonLDOrganization()
+
+    person.WorksFor = &organization
+  }
+
+  return person
+}
+
+
+// JSONLD returns the network as a schema.org Place, located from its geolocation
+func (instance Network) JSONLD() JSONLDPlace {
+  place := JSONLDPlace{
+    Context: jsonLDContext,
+    Type: "Place",
+    Identifier: stringValue(instance.IP),
+  }
+
+  if instance.Block != nil && instance.Block.Owner != nil {
+    place.Name = stringValue(instance.Block.Owner.Organization)
+  }
+
+  if instance.Geolocation != nil {
+    place.Address = newJSONLDPostalAddress(&Address{
+      City: instance.Geolocation.City,
+      Region: instance.Geolocation.Region,
+      Country: instance.Geolocation.Country,
+    })
+
+    place.Geo = newJSONLDGeoCoordinates(instance.Geolocation.Coordinates)
+  }
+
+  return place
+}
+
+
+// JSONLDScript serializes a JSON-LD document as a script tag, ready to be embedded in an HTML page
+func JSONLDScript(document interface{}) (string, error) {
+  // HTML characters are escaped by the encoder, so that the document cannot close the script tag
+  data, err := json.Marshal(document)
+  if err != nil {
+    return "", err
+  }
+
+  return "<script type=\"application/ld+json\">" + string(data) + "</script>", nil
+}
+
+
+// jsonLDOrganization returns the company as a schema.org Organization, without context
+func (instance Company) jsonLDOrganization() JSONLDOrganization {
+  organization := JSONLDOrganization{
+    Type: "Organization",
+    Name: stringValue(instance.Name),
+    LegalName: stringValue(instance.LegalName),
+    Description: stringValue(instance.Description),
+    Logo: stringValue(instance.Logo),
+    Address: newJSONLDPostalAddress(instance.Address),
+  }
+
+  if instance.Kind != nil {
+    if kind, ok := jsonLDOrganizationTypes[ParseCompanyKind(string(*instance.Kind))]; ok == true {
+      organization.Type = kind
+    }
+  }
+
+  if instance.Founded != nil && *instance.Founded > 0 {
+    organization.FoundingDate = strconv.Itoa(int(*instance.Founded))
+  }
+
+  var region string
+
+  if instance.Address != nil && instance.Address.Country != nil {
+    region = *instance.Address.Country
+  }
+
+  organization.Email, organization.Telephone, organization.URL = jsonLDContact(instance.Contact, region)
+
+  if organization.URL == "" && instance.Contact != nil && instance.Contact.Domain != nil && *instance.Contact.Domain != "" {
+    organization.URL = "https://" + *instance.Contact.Domain
+  }
+
+  if instance.Metrics != nil {
+    if employees, ok := instance.Metrics.EmployeeRange(); ok == true {
+      organization.NumberOfEmployees = newJSONLDQuantitativeValue(employees)
+    }
+  }
+
+  for _, profile := range instance.SocialProfiles() {
+    organization.SameAs = append(organization.SameAs, profile.URL)
+  }
+
+  return organization
+}
+
+
+// jsonLDContact returns the first email, first phone (normalized if possible) and website of a contact
+func jsonLDContact(contact *Contact, region string) (string, string, string) {
+  var email, telephone string
+
+  if contact == nil {
+    return "", "", ""
+  }
+
+  if contact.Emails != nil && len(*contact.Emails) > 0 {
+    email = (*contact.Emails)[0]
+  }
+
+  if phones := normalizePhones(contact, region); len(phones) > 0 {
+    telephone = phones[0].String()
+  }
+
+  return email, telephone, stringValue(contact.Website)
+}
+
+
+// newJSONLDPostalAddress returns an address as a schema.org PostalAddress (or nil if empty)
+func newJSONLDPostalAddress(address *Address) *JSONLDPostalAddress {
+  if address == nil {
+    return nil
+  }
+
+  postalAddress := &JSONLDPostalAddress{
+    Type: "PostalAddress",
+    StreetAddress: stringValue(address.Street),
+    AddressLocality: stringValue(address.City),
+    AddressRegion: stringValue(address.Region),
+    PostalCode: stringValue(address.Postcode),
+    AddressCountry: stringValue(address.Country),
+  }
+
+  if *postalAddress == (JSONLDPostalAddress{Type: "PostalAddress"}) {
+    return nil
+  }
+
+  return postalAddress
+}
+
+
+// newJSONLDGeoCoordinates returns coordinates as schema.org GeoCoordinates (or nil if invalid)
+func newJSONLDGeoCoordinates(coordinates *Coordinates) *JSONLDGeoCoordinates {
+  if coordinates == nil || coordinates.Valid() == false {
+    return nil
+  }
+
+  return &JSONLDGeoCoordinates{
+    Type: "GeoCoordinates",
+    Latitude: exactFloat64(*coordinates.Latitude),
+    Longitude: exactFloat64(*coordinates.Longitude),
+  }
+}
+
+
+// newJSONLDQuantitativeValue returns an employee range as a schema.org QuantitativeValue
+func newJSONLDQuantitativeValue(employees *EmployeeRange) *JSONLDQuantitativeValue {
+  value := &JSONLDQuantitativeValue{Type: "QuantitativeValue"}
+
+  if employees.Exact == true {
+    value.Value = &employees.Min
+
+    return value
+  }
+
+  value.MinValue = &employees.Min
+
+  // A zero maximum means unbounded
+  if employees.Max != 0 {
+    value.MaxValue = &employees.Max
+  }
+
+  return value
+}
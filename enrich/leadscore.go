@@ -0,0 +1,334 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "encoding/json"
+  "fmt"
+  "io"
+  "io/ioutil"
+  "strconv"
+  "strings"
+)
+
+
+// Lead criterion fields
+const (
+  LeadFieldSeniority = "seniority"
+  LeadFieldRole = "role"
+  LeadFieldTitle = "title"
+  LeadFieldIndustry = "industry"
+  LeadFieldEmployees = "employees"
+  LeadFieldRevenue = "revenue"
+  LeadFieldCountry = "country"
+  LeadFieldCompanyCountry = "company_country"
+  LeadFieldCompanyKind = "company_kind"
+)
+
+const leadScoreMax = 100
+
+// leadNumericFields lists lead fields compared using min and max bounds
+var leadNumericFields = []string{LeadFieldEmployees, LeadFieldRevenue}
+
+// leadTextFields lists lead fields compared using values and substrings
+var leadTextFields = []string{
+  LeadFieldSeniority,
+  LeadFieldRole,
+  LeadFieldTitle,
+  LeadFieldIndustry,
+  LeadFieldCountry,
+  LeadFieldCompanyCountry,
+  LeadFieldCompanyKind,
+}
+
+
+// LeadModel maps a declarative lead scoring model (eg. an ideal customer profile)
+type LeadModel struct {
+  Name       string           `json:"name,omitempty"`
+  Criteria   []LeadCriterion  `json:"criteria"`
+  Threshold  float32          `json:"threshold,omitempty"`
+  Currency   string           `json:"currency,omitempty"`
+  Rates      CurrencyRates    `json:"rates,omitempty"`
+}
+
+// LeadCriterion maps a scoring criterion of a lead model
+type LeadCriterion struct {
+  Name      string    `json:"name"`
+  Field     string    `json:"field"`
+  Values    []string  `json:"values,omitempty"`
+  Contains  []string  `json:"contains,omitempty"`
+  Min       *float64  `json:"min,omitempty"`
+  Max       *float64  `json:"max,omitempty"`
+  Weight    float32   `json:"weight"`
+  Required  bool      `json:"required,omitempty"`
+}
+
+// LeadScore maps the result of a lead scoring
+type LeadScore struct {
+  Score      float32                `json:"score"`
+  Qualified  bool                   `json:"qualified"`
+  Breakdown  []LeadCriterionResult  `json:"breakdown"`
+}
+
+// LeadCriterionResult maps the result of a lead criterion
+type LeadCriterionResult struct {
+  Name      string   `json:"name"`
+  Field     string   `json:"field"`
+  Value     string   `json:"value,omitempty"`
+  Known     bool     `json:"known"`
+  Matched   bool     `json:"matched"`
+  Required  bool     `json:"required,omitempty"`
+  Points    float32  `json:"points"`
+  Weight    float32  `json:"weight"`
+}
+
+// leadFacts maps the values extracted from enriched data, for each lead field
+type leadFacts map[string]string
+
+
+// String returns the string representation of LeadModel
+func (instance LeadModel) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of LeadCriterion
+func (instance LeadCriterion) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of LeadScore
+func (instance LeadScore) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of LeadCriterionResult
+func (instance LeadCriterionResult) String() string {
+  return Stringify(instance)
+}
+
+
+// ParseLeadModel parses a JSON lead model
+func ParseLeadModel(data []byte) (*LeadModel, error) {
+  model := &LeadModel{}
+
+  if err := json.Unmarshal(data, model); err != nil {
+    return nil, err
+  }
+
+  if err := model.Validate(); err != nil {
+    return nil, err
+  }
+
+  return model, nil
+}
+
+
+// LoadLeadModel reads and parses a JSON lead model
+func LoadLeadModel(reader io.Reader) (*LeadModel, error) {
+  data, err := ioutil.ReadAll(reader)
+  if err != nil {
+    return nil, err
+  }
+
+  return ParseLeadModel(data)
+}
+
+
+// Validate checks that a lead model only uses known fields, with consistent conditions
+func (model *LeadModel) Validate() error {
+  for _, criterion := range model.Criteria {
+    isNumeric := containsString(leadNumericFields, criterion.Field)
+
+    if isNumeric == false && containsString(leadTextFields, criterion.Field) == false {
+      return fmt.Errorf("unknown field in lead criterion %q: %q", criterion.Name, criterion.Field)
+    }
+    if criterion.Weight < 0 {
+      return fmt.Errorf("negative weight in lead criterion %q", criterion.Name)
+    }
+
+    if isNumeric == true && (len(criterion.Values) > 0 || len(criterion.Contains) > 0) {
+      return fmt.Errorf("numeric lead criterion %q cannot use values or contains", criterion.Name)
+    }
+    if isNumeric == false && (criterion.Min != nil || criterion.Max != nil) {
+      return fmt.Errorf("text lead criterion %q cannot use min or max", criterion.Name)
+    }
+  }
+
+  return nil
+}
+
+
+// Evaluate scores enriched person data against the lead model
+func (model *LeadModel) Evaluate(data *EnrichPersonData) *LeadScore {
+  facts := model.extractFacts(data)
+  score := &LeadScore{Qualified: true}
+
+  var points float32
+  var total float32
+
+  for _, criterion := range model.Criteria {
+    value, known := facts[criterion.Field]
+
+    result := LeadCriterionResult{
+      Name: criterion.Name,
+      Field: criterion.Field,
+      Value: value,
+      Known: known,
+      Required: criterion.Required,
+      Weight: criterion.Weight,
+    }
+
+    if known == true {
+      result.Matched = criterion.matches(value)
+    }
+
+    if result.Matched == true {
+      result.Points = criterion.Weight
+    } else if criterion.Required == true {
+      score.Qualified = false
+    }
+
+    points += result.Points
+    total += criterion.Weight
+
+    score.Breakdown = append(score.Breakdown, result)
+  }
+
+  if total > 0 {
+    score.Score = points / total * leadScoreMax
+  }
+
+  if score.Score < model.Threshold {
+    score.Qualified = false
+  }
+
+  return score
+}
+
+
+// matches returns whether a known value satisfies the criterion
+func (criterion *LeadCriterion) matches(value string) bool {
+  if containsString(leadNumericFields, criterion.Field) == true {
+    number, err := strconv.ParseFloat(value, 64)
+    if err != nil {
+      return false
+    }
+
+    return (criterion.Min == nil || number >= *criterion.Min) && (criterion.Max == nil || number <= *criterion.Max)
+  }
+
+  if len(criterion.Values) == 0 && len(criterion.Contains) == 0 {
+    return true
+  }
+
+  for _, expected := range criterion.Values {
+    if strings.EqualFold(value, expected) == true {
+      return true
+    }
+  }
+
+  for _, expected := range criterion.Contains {
+    if strings.Contains(strings.ToLower(value), strings.ToLower(expected)) == true {
+      return true
+    }
+  }
+
+  return false
+}
+
+
+// extractFacts extracts the value of each lead field from enriched person data
+func (model *LeadModel) extractFacts(data *EnrichPersonData) leadFacts {
+  facts := make(leadFacts)
+
+  if data == nil {
+    return facts
+  }
+
+  var employment *PersonEmployment
+
+  if person := data.Person; person != nil {
+    if person.Employments != nil && len(*person.Employments) > 0 {
+      employment = &(*person.Employments)[0]
+
+      facts.set(LeadFieldSeniority, (*string)(employment.Seniority))
+      facts.set(LeadFieldRole, (*string)(employment.Role))
+      facts.set(LeadFieldTitle, employment.Title)
+    }
+
+    if person.Address != nil {
+      facts.set(LeadFieldCountry, person.Address.Country)
+    }
+    if _, ok := facts[LeadFieldCountry]; ok == false && person.Geolocation != nil {
+      facts.set(LeadFieldCountry, person.Geolocation.Country)
+    }
+  }
+
+  if company := leadCompany(data, employment); company != nil {
+    facts.set(LeadFieldCompanyKind, (*string)(company.Kind))
+
+    if company.Category != nil {
+      facts.set(LeadFieldIndustry, company.Category.Industry)
+    }
+    if company.Address != nil {
+      facts.set(LeadFieldCompanyCountry, company.Address.Country)
+    }
+
+    if company.Metrics != nil {
+      if employees, ok := company.Metrics.EmployeeRange(); ok == true {
+        facts[LeadFieldEmployees] = strconv.FormatUint(uint64(employees.Midpoint()), 10)
+      }
+
+      if company.Metrics.AnnualRevenue != nil {
+        revenue := company.Metrics.AnnualRevenue
+
+        if model.Currency != "" {
+          if amount, err := revenue.Convert(model.Currency, model.Rates); err == nil {
+            facts[LeadFieldRevenue] = strconv.FormatInt(amount, 10)
+          }
+        } else if revenue.Amount != nil {
+          facts[LeadFieldRevenue] = strconv.FormatInt(*revenue.Amount, 10)
+        }
+      }
+    }
+  }
+
+  return facts
+}
+
+
+// set stores a fact if its value is known
+func (facts leadFacts) set(field string, value *string) {
+  if value != nil && *value != "" {
+    facts[field] = *value
+  }
+}
+
+
+// leadCompany returns the company matching the current employment (or the first company)
+func leadCompany(data *EnrichPersonData, employment *PersonEmployment) *Company {
+  if data.Companies == nil || len(*data.Companies) == 0 {
+    return nil
+  }
+
+  companies := *data.Companies
+
+  if employment != nil {
+    for i := range companies {
+      company := &companies[i]
+
+      if employment.ID != nil && company.ID != nil && *employment.ID == *company.ID {
+        return company
+      }
+      if employment.Domain != nil && company.Contact != nil && company.Contact.Domain != nil && strings.EqualFold(*employment.Domain, *company.Contact.Domain) {
+        return company
+      }
+    }
+  }
+
+  return &companies[0]
+}
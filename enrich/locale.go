@@ -0,0 +1,145 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "strings"
+)
+
+
+// Locale maps a language and an optional region (eg. 'en-US')
+type Locale struct {
+  Language  string
+  Region    string
+}
+
+
+// String returns the string representation of Locale (eg. 'en-US')
+func (instance Locale) String() string {
+  if instance.Region == "" {
+    return instance.Language
+  }
+
+  return instance.Language + "-" + instance.Region
+}
+
+
+// ParseLocale parses a locale (eg. 'en', 'en-US', 'en_us' or 'zh-Hant-TW')
+func ParseLocale(value string) (Locale, bool) {
+  value = strings.TrimSpace(value)
+
+  // Strip any encoding or modifier (eg. 'fr_FR.UTF-8@euro')
+  if index := strings.IndexAny(value, ".@"); index >= 0 {
+    value = value[:index]
+  }
+
+  parts := strings.FieldsFunc(value, func(character rune) bool {
+    return character == '-' || character == '_'
+  })
+
+  if len(parts) == 0 || len(parts[0]) < 2 || len(parts[0]) > 3 || isLetters(parts[0]) == false {
+    return Locale{}, false
+  }
+
+  locale := Locale{Language: strings.ToLower(parts[0])}
+
+  for _, part := range parts[1:] {
+    // Regions are 2 letters (eg. 'US') or 3 digits (eg. '419'), other parts are scripts or variants
+    if (len(part) == 2 && isLetters(part) == true) || (len(part) == 3 && isDigits(part) == true) {
+      locale.Region = strings.ToUpper(part)
+      break
+    }
+  }
+
+  return locale, true
+}
+
+
+// ParsedLocales returns the parsed locales of the person, ignoring invalid ones
+func (instance Person) ParsedLocales() []Locale {
+  var locales []Locale
+
+  if instance.Locales == nil {
+    return nil
+  }
+
+  for _, value := range *instance.Locales {
+    if locale, ok := ParseLocale(value); ok == true {
+      locales = append(locales, locale)
+    }
+  }
+
+  return locales
+}
+
+
+// BestLocale returns the supported locale best matching the person locales
+func (instance Person) BestLocale(supported []string) (Locale, bool) {
+  return MatchLocale(instance.ParsedLocales(), supported)
+}
+
+
+// MatchLocale returns the supported locale best matching preferred locales (by order of preference)
+//
+// An exact language and region match wins, then a language match (preferring a supported locale without region).
+func MatchLocale(preferred []Locale, supported []string) (Locale, bool) {
+  var candidates []Locale
+
+  for _, value := range supported {
+    if locale, ok := ParseLocale(value); ok == true {
+      candidates = append(candidates, locale)
+    }
+  }
+
+  for _, wanted := range preferred {
+    var languageMatch *Locale
+
+    for i, candidate := range candidates {
+      if candidate.Language != wanted.Language {
+        continue
+      }
+
+      if candidate.Region == wanted.Region {
+        return candidate, true
+      }
+
+      if languageMatch == nil || (languageMatch.Region != "" && candidate.Region == "") {
+        languageMatch = &candidates[i]
+      }
+    }
+
+    if languageMatch != nil {
+      return *languageMatch, true
+    }
+  }
+
+  return Locale{}, false
+}
+
+
+// isLetters returns whether a string only has ASCII letters
+func isLetters(value string) bool {
+  for _, character := range value {
+    if (character < 'a' || character > 'z') && (character < 'A' || character > 'Z') {
+      return false
+    }
+  }
+
+  return true
+}
+
+
+// isDigits returns whether a string only has ASCII digits
+func isDigits(value string) bool {
+  for _, character := range value {
+    if character < '0' || character > '9' {
+      return false
+    }
+  }
+
+  return true
+}
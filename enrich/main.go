@@ -9,40 +9,47 @@ package enrich
 import (
   "bytes"
   "encoding/json"
-  "fmt"
   "time"
   "io"
   "io/ioutil"
+  "mime"
   "net/http"
   "net/url"
+  "strings"
+  "sync"
+  "unicode/utf8"
 )
 
 
 const (
-  libraryVersion = "2.0.0"
+  libraryVersion = "3.0.0"
   defaultRestEndpointURL = "https://api.enrich.email/v1/"
   userAgent = "enrich-api-go/" + libraryVersion
   acceptContentType = "application/json"
   clientTimeout = 40
+  errorBodyLimit = 64 << 10
+  errorSnippetLength = 256
+  defaultRawBodyLimit = 1 << 20
 )
 
 // ClientConfig mapping
 type ClientConfig struct {
   HTTPClient *http.Client
   RestEndpointURL string
-}
-
-type auth struct {
-  Available bool
-  Username string
-  Password string
+  StrictDecoding bool
+  KeepRawBody bool
+  RawBodyLimit int64
+  DisableDecoding bool
+  Credentials CredentialsProvider
 }
 
 // Client maps an API client
 type Client struct {
   config *ClientConfig
   client *http.Client
-  auth *auth
+
+  credentialsLock sync.RWMutex
+  credentials CredentialsProvider
 
   BaseURL *url.URL
   UserAgent string
@@ -51,6 +58,8 @@ type Client struct {
 
   Verify *VerifyService
   Enrich *EnrichService
+  Finder *FinderService
+  Account *AccountService
 }
 
 type service struct {
@@ -60,6 +69,15 @@ type service struct {
 // Response maps an API HTTP response
 type Response struct {
   *http.Response
+
+  Meta *ResponseMeta
+  RawBody []byte
+  RawBodyTruncated bool
+}
+
+type rawBodyReader struct {
+  io.Reader
+  io.Closer
 }
 
 type errorResponse struct {
@@ -72,12 +90,6 @@ type errorResponseError struct {
 }
 
 
-// Error prints an error response
-func (response *errorResponseError) Error() string {
-  return fmt.Sprintf("%v %v", response.Reason, response.Message)
-}
-
-
 // NewWithConfig returns a new API client
 func NewWithConfig(config ClientConfig) *Client {
   // Defaults
@@ -88,16 +100,26 @@ func NewWithConfig(config ClientConfig) *Client {
   if config.RestEndpointURL == "" {
     config.RestEndpointURL = defaultRestEndpointURL
   }
+  if config.RawBodyLimit <= 0 {
+    config.RawBodyLimit = defaultRawBodyLimit
+  }
+
+  // Responses are only readable from their raw body when decoding is disabled
+  if config.DisableDecoding == true {
+    config.KeepRawBody = true
+  }
 
   // Create client
   baseURL, _ := url.Parse(config.RestEndpointURL)
 
-  client := &Client{config: &config, client: config.HTTPClient, auth: &auth{}, BaseURL: baseURL, UserAgent: userAgent}
+  client := &Client{config: &config, client: config.HTTPClient, credentials: config.Credentials, BaseURL: baseURL, UserAgent: userAgent}
   client.common.client = client
 
   // Map services
   client.Verify = (*VerifyService)(&client.common)
   client.Enrich = (*EnrichService)(&client.common)
+  client.Finder = (*FinderService)(&client.common)
+  client.Account = (*AccountService)(&client.common)
 
   return client
 }
@@ -111,9 +133,25 @@ func New() *Client {
 
 // Authenticate saves authentication parameters
 func (client *Client) Authenticate(username string, password string) {
-  client.auth.Username = username
-  client.auth.Password = password
-  client.auth.Available = true
+  client.SetCredentialsProvider(NewStaticCredentials(username, password))
+}
+
+
+// SetCredentialsProvider sets the provider consulted for credentials on each request
+func (client *Client) SetCredentialsProvider(provider CredentialsProvider) {
+  client.credentialsLock.Lock()
+  defer client.credentialsLock.Unlock()
+
+  client.credentials = provider
+}
+
+
+// credentialsProvider returns the current credentials provider
+func (client *Client) credentialsProvider() CredentialsProvider {
+  client.credentialsLock.RLock()
+  defer client.credentialsLock.RUnlock()
+
+  return client.credentials
 }
 
 
@@ -140,8 +178,13 @@ func (client *Client) NewRequest(method, urlStr string, body interface{}) (*http
     return nil, err
   }
 
-  if client.auth.Available == true {
-    req.SetBasicAuth(client.auth.Username, client.auth.Password)
+  if provider := client.credentialsProvider(); provider != nil {
+    credentials, err := provider.Credentials()
+    if err != nil {
+      return nil, err
+    }
+
+    req.SetBasicAuth(credentials.Username, credentials.Password)
   }
 
   req.Header.Add("Accept", acceptContentType)
@@ -157,7 +200,62 @@ func (client *Client) NewRequest(method, urlStr string, body interface{}) (*http
 
 // Do sends an API request
 func (client *Client) Do(req *http.Request, v interface{}) (*Response, error) {
-  return client.DoInner(req, v)
+  resp, err := client.DoInner(req, v)
+
+  for attempt := 0; attempt < maxCredentialsFailovers; attempt++ {
+    retryReq := client.failoverRequest(req, err)
+    if retryReq == nil {
+      break
+    }
+
+    req = retryReq
+    resp, err = client.DoInner(req, v)
+  }
+
+  return resp, err
+}
+
+
+// failoverRequest returns a copy of the request using other credentials, if the provider can fail over
+func (client *Client) failoverRequest(req *http.Request, err error) *http.Request {
+  responseError, ok := err.(*ResponseError)
+  if ok == false {
+    return nil
+  }
+
+  provider, ok := client.credentialsProvider().(CredentialsFailover)
+  if ok == false {
+    return nil
+  }
+
+  username, password, ok := req.BasicAuth()
+  if ok == false || provider.Failover(&Credentials{Username: username, Password: password}, responseError) == false {
+    return nil
+  }
+
+  credentials, credentialsErr := client.credentialsProvider().Credentials()
+  if credentialsErr != nil {
+    return nil
+  }
+
+  retryReq := req.Clone(req.Context())
+
+  if req.Body != nil && req.Body != http.NoBody {
+    if req.GetBody == nil {
+      return nil
+    }
+
+    body, bodyErr := req.GetBody()
+    if bodyErr != nil {
+      return nil
+    }
+
+    retryReq.Body = body
+  }
+
+  retryReq.SetBasicAuth(credentials.Username, credentials.Password)
+
+  return retryReq
 }
 
 
@@ -168,20 +266,32 @@ func (client *Client) DoInner(req *http.Request, v interface{}) (*Response, erro
     return nil, err
   }
 
+  body := resp.Body
+
   defer func() {
-    io.CopyN(ioutil.Discard, resp.Body, 512)
-    resp.Body.Close()
+    io.CopyN(ioutil.Discard, body, 512)
+    body.Close()
   }()
 
   response := newResponse(resp)
 
+  if client.config.KeepRawBody == true {
+    captureRawBody(response, client.config.RawBodyLimit)
+  }
+
   err = checkResponse(resp)
   if err != nil {
     return response, err
   }
 
-  if decodeResponse(resp, v) == true {
-    err = nil
+  if client.config.DisableDecoding == true {
+    return response, nil
+  }
+
+  err = decodeResponse(resp, v)
+
+  if err == nil && client.config.StrictDecoding == true && v != nil {
+    err = checkUnknownFields(v)
   }
 
   return response, err
@@ -190,35 +300,150 @@ func (client *Client) DoInner(req *http.Request, v interface{}) (*Response, erro
 
 // newResponse creates an HTTP response
 func newResponse(httpResponse *http.Response) *Response {
-  response := &Response{Response: httpResponse}
+  response := &Response{Response: httpResponse, Meta: newResponseMeta(httpResponse)}
 
   return response
 }
 
 
+// captureRawBody keeps up to limit bytes of the response body, while leaving the full body readable
+func captureRawBody(response *Response, limit int64) {
+  raw, _ := ioutil.ReadAll(io.LimitReader(response.Body, limit + 1))
+
+  if int64(len(raw)) > limit {
+    response.RawBody = raw[:limit]
+    response.RawBodyTruncated = true
+  } else {
+    response.RawBody = raw
+  }
+
+  response.Body = &rawBodyReader{Reader: io.MultiReader(bytes.NewReader(raw), response.Body), Closer: response.Body}
+}
+
+
 // checkResponse checks response for errors
 func checkResponse(response *http.Response) error {
   if code := response.StatusCode; 200 <= code && code <= 299 {
     return nil
   }
+  body, _ := ioutil.ReadAll(io.LimitReader(response.Body, errorBodyLimit))
+
+  responseError := &ResponseError{
+    StatusCode: response.StatusCode,
+    ContentType: response.Header.Get("Content-Type"),
+    Snippet: truncateSnippet(body, errorSnippetLength),
+  }
+
   errorResponse := &errorResponse{}
 
-  if json.NewDecoder(response.Body).Decode(errorResponse) != nil {
-    errorResponse.Error = errorResponseError{Reason: "error", Message: "Request could not be submitted."}
+  if json.Unmarshal(body, errorResponse) == nil && errorResponse.Error.Reason != "" {
+    responseError.Reason = errorResponse.Error.Reason
+    responseError.Message = errorResponse.Error.Message
+  } else {
+    responseError.Reason = reasonForStatus(response.StatusCode)
+    responseError.Message = "Request could not be submitted."
+  }
+
+  return responseError
+}
+
+
+// reasonForStatus maps an HTTP status code to an error reason, when the API did not provide one
+func reasonForStatus(code int) string {
+  switch {
+    case code == http.StatusBadRequest:
+      return "bad_request"
+
+    case code == http.StatusUnauthorized:
+      return "unauthorized"
+
+    case code == http.StatusPaymentRequired:
+      return "quota_exceeded"
+
+    case code == http.StatusForbidden:
+      return "forbidden"
+
+    case code == http.StatusNotFound:
+      return "not_found"
+
+    case code == http.StatusTooManyRequests:
+      return "rate_limited"
+
+    case code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout:
+      return "gateway_error"
+
+    case code >= 500:
+      return "server_error"
   }
 
-  return &errorResponse.Error
+  return "error"
+}
+
+
+// truncateSnippet returns the beginning of a body, cut on a valid UTF-8 boundary
+func truncateSnippet(body []byte, length int) string {
+  if len(body) <= length {
+    return strings.TrimSpace(string(body))
+  }
+
+  for length > 0 && utf8.RuneStart(body[length]) == false {
+    length--
+  }
+
+  return strings.TrimSpace(string(body[:length])) + "..."
 }
 
 
 // decodeResponse decodes response body
-func decodeResponse(resp *http.Response, v interface{}) bool {
-  if v != nil {
-    if w, ok := v.(io.Writer); ok {
-      io.Copy(w, resp.Body)
-    } else if json.NewDecoder(resp.Body).Decode(v) == io.EOF {
-      return true
+func decodeResponse(resp *http.Response, v interface{}) error {
+  if v == nil {
+    return nil
+  }
+
+  if w, ok := v.(io.Writer); ok {
+    _, err := io.Copy(w, resp.Body)
+
+    return err
+  }
+
+  if err := checkContentType(resp); err != nil {
+    return err
+  }
+
+  decoder := json.NewDecoder(resp.Body)
+
+  err := decoder.Decode(v)
+  if err == io.EOF {
+    if resp.StatusCode == http.StatusOK {
+      return &EmptyBodyError{StatusCode: resp.StatusCode}
     }
+
+    return nil
   }
-  return false
+  if err != nil {
+    return &DecodeError{StatusCode: resp.StatusCode, Err: err}
+  }
+
+  // Ensure that nothing follows the decoded JSON value
+  if _, err := decoder.Token(); err != io.EOF {
+    return &DecodeError{StatusCode: resp.StatusCode, Err: errTrailingData}
+  }
+
+  return nil
+}
+
+
+// checkContentType checks that the response body is JSON (an empty content type is tolerated)
+func checkContentType(resp *http.Response) error {
+  contentType := resp.Header.Get("Content-Type")
+  if contentType == "" {
+    return nil
+  }
+
+  mediaType, _, err := mime.ParseMediaType(contentType)
+  if err != nil || (mediaType != acceptContentType && strings.HasSuffix(mediaType, "+json") == false) {
+    return &ContentTypeError{StatusCode: resp.StatusCode, ContentType: contentType}
+  }
+
+  return nil
 }
@@ -0,0 +1,426 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "encoding/json"
+  "fmt"
+  "reflect"
+  "sort"
+  "strconv"
+  "strings"
+  "time"
+)
+
+
+// MergeStrategy maps how a field is resolved when merging enrichment results
+type MergeStrategy string
+
+// MergeSource maps an enrichment result to be merged, with the lookup it came from (eg. 'email:john@acme.com')
+type MergeSource struct {
+  Lookup     string
+  FetchedAt  time.Time
+  Data       interface{}
+}
+
+// MergePolicy maps the merge strategies, per field (as JSON paths, eg. 'contact.emails')
+//
+// A field strategy also applies to all of its sub-fields, unless they have their own.
+type MergePolicy struct {
+  Default  MergeStrategy             `json:"default"`
+  Fields   map[string]MergeStrategy  `json:"fields,omitempty"`
+}
+
+// Provenance maps each merged field (as a JSON path) to the lookups it came from
+type Provenance map[string][]string
+
+// mergeCandidate maps a value from a source, being merged
+type mergeCandidate struct {
+  lookup     string
+  fetchedAt  time.Time
+  value      reflect.Value
+}
+
+// mergeGroup maps slice items identifying the same entity, across sources
+type mergeGroup struct {
+  candidates  []mergeCandidate
+}
+
+
+// Merge strategies
+const (
+  MergePreferNonNil MergeStrategy = "prefer_non_nil"
+  MergePreferNewest MergeStrategy = "prefer_newest"
+  MergeUnion MergeStrategy = "union"
+)
+
+var extraType = reflect.TypeOf(map[string]json.RawMessage(nil))
+
+
+// String returns the string representation of MergePolicy
+func (instance MergePolicy) String() string {
+  return Stringify(instance)
+}
+
+
+// NewMergePolicy returns a merge policy keeping the first non-nil value of each field
+func NewMergePolicy() *MergePolicy {
+  return &MergePolicy{Default: MergePreferNonNil, Fields: make(map[string]MergeStrategy)}
+}
+
+
+// Validate checks that the merge policy only has known strategies
+func (policy *MergePolicy) Validate() error {
+  if policy.Default != "" && validMergeStrategy(policy.Default) == false {
+    return fmt.Errorf("unknown default merge strategy: %q", policy.Default)
+  }
+
+  for path, strategy := range policy.Fields {
+    if validMergeStrategy(strategy) == false {
+      return fmt.Errorf("unknown merge strategy for field %q: %q", path, strategy)
+    }
+  }
+
+  return nil
+}
+
+
+// Merge deep-merges sources into a destination (a pointer to a model, eg. *Person), returning the provenance of fields
+//
+// All sources must hold the destination model (or a pointer to it), and are given by order of preference (used for 'prefer_non_nil' fields, and to break ties on 'prefer_newest' fields).
+//
+// Slice items with the same ID are merged together on 'union' fields, other items are deduplicated. Merged values may share memory with sources.
+func (policy *MergePolicy) Merge(destination interface{}, sources ...MergeSource) (Provenance, error) {
+  if err := policy.Validate(); err != nil {
+    return nil, err
+  }
+
+  target := reflect.ValueOf(destination)
+
+  if target.Kind() != reflect.Ptr || target.IsNil() == true || target.Elem().Kind() != reflect.Struct {
+    return nil, fmt.Errorf("merge destination must be a pointer to a struct, got %T", destination)
+  }
+
+  kind := target.Elem().Type()
+
+  var candidates []mergeCandidate
+
+  for _, source := range sources {
+    value := reflect.ValueOf(source.Data)
+
+    if value.Kind() == reflect.Ptr {
+      if value.IsNil() == true {
+        continue
+      }
+
+      value = value.Elem()
+    }
+
+    if value.IsValid() == false {
+      continue
+    }
+
+    if value.Type() != kind {
+      return nil, fmt.Errorf("merge source %q holds %s, expected %s", source.Lookup, value.Type(), kind)
+    }
+
+    candidates = append(candidates, mergeCandidate{lookup: source.Lookup, fetchedAt: source.FetchedAt, value: value})
+  }
+
+  provenance := make(Provenance)
+
+  target.Elem().Set(policy.mergeStruct("", kind, candidates, provenance))
+
+  return provenance, nil
+}
+
+
+// Lookups returns the lookups a field and its sub-fields came from
+func (provenance Provenance) Lookups(path string) []string {
+  var lookups []string
+
+  for field, sources := range provenance {
+    if path == "" || field == path || strings.HasPrefix(field, path + ".") == true {
+      for _, lookup := range sources {
+        if containsString(lookups, lookup) == false {
+          lookups = append(lookups, lookup)
+        }
+      }
+    }
+  }
+
+  sort.Strings(lookups)
+
+  return lookups
+}
+
+
+// mergeStruct merges struct candidates, field by field
+func (policy *MergePolicy) mergeStruct(path string, kind reflect.Type, candidates []mergeCandidate, provenance Provenance) reflect.Value {
+  result := reflect.New(kind).Elem()
+
+  for i := 0; i < kind.NumField(); i++ {
+    field := kind.Field(i)
+
+    if field.PkgPath != "" {
+      continue
+    }
+
+    values := make([]mergeCandidate, len(candidates))
+
+    for j, candidate := range candidates {
+      values[j] = candidate
+      values[j].value = candidate.value.Field(i)
+    }
+
+    // Unknown fields are merged key by key
+    if field.Name == "Extra" && field.Type == extraType {
+      result.Field(i).Set(policy.mergeExtra(path, values, provenance))
+
+      continue
+    }
+
+    if name := jsonFieldName(field); name != "" {
+      result.Field(i).Set(policy.mergeValue(joinFieldPath(path, name), field.Type, values, provenance))
+    }
+  }
+
+  return result
+}
+
+
+// mergeValue merges candidate values of a field, recursing in structs
+func (policy *MergePolicy) mergeValue(path string, kind reflect.Type, candidates []mergeCandidate, provenance Provenance) reflect.Value {
+  var present []mergeCandidate
+
+  for _, candidate := range candidates {
+    if isEmptyMergeValue(candidate.value) == false {
+      present = append(present, candidate)
+    }
+  }
+
+  if len(present) == 0 {
+    return reflect.Zero(kind)
+  }
+
+  base := kind
+
+  if kind.Kind() == reflect.Ptr {
+    base = kind.Elem()
+
+    for i := range present {
+      present[i].value = present[i].value.Elem()
+    }
+  }
+
+  var merged reflect.Value
+
+  switch {
+    case base.Kind() == reflect.Struct:
+      merged = policy.mergeStruct(path, base, present, provenance)
+
+    case base.Kind() == reflect.Slice && policy.strategyFor(path) == MergeUnion:
+      merged = policy.unionSlices(path, base, present, provenance)
+
+    default:
+      chosen := policy.choose(path, present)
+
+      provenance[path] = []string{chosen.lookup}
+      merged = chosen.value
+  }
+
+  if kind.Kind() == reflect.Ptr {
+    pointer := reflect.New(base)
+    pointer.Elem().Set(merged)
+
+    return pointer
+  }
+
+  return merged
+}
+
+
+// unionSlices unions slice candidates, deduplicating items (and merging items with the same ID)
+func (policy *MergePolicy) unionSlices(path string, kind reflect.Type, candidates []mergeCandidate, provenance Provenance) reflect.Value {
+  var groups []*mergeGroup
+  var lookups []string
+
+  for _, candidate := range candidates {
+    for i := 0; i < candidate.value.Len(); i++ {
+      item := candidate
+      item.value = candidate.value.Index(i)
+
+      var matched *mergeGroup
+
+      for _, group := range groups {
+        if sameMergeItem(group.candidates[0].value, item.value) == true {
+          matched = group
+          break
+        }
+      }
+
+      if matched == nil {
+        groups = append(groups, &mergeGroup{candidates: []mergeCandidate{item}})
+      } else {
+        matched.candidates = append(matched.candidates, item)
+      }
+
+      if containsString(lookups, candidate.lookup) == false {
+        lookups = append(lookups, candidate.lookup)
+      }
+    }
+  }
+
+  result := reflect.MakeSlice(kind, 0, len(groups))
+
+  for index, group := range groups {
+    if len(group.candidates) > 1 && isMergeStruct(kind.Elem()) == true {
+      result = reflect.Append(result, policy.mergeValue(joinFieldPath(path, strconv.Itoa(index)), kind.Elem(), group.candidates, provenance))
+    } else {
+      result = reflect.Append(result, group.candidates[0].value)
+    }
+  }
+
+  provenance[path] = lookups
+
+  return result
+}
+
+
+// mergeExtra merges unknown fields, key by key
+func (policy *MergePolicy) mergeExtra(path string, candidates []mergeCandidate, provenance Provenance) reflect.Value {
+  var keys []string
+
+  for _, candidate := range candidates {
+    for _, key := range candidate.value.MapKeys() {
+      if containsString(keys, key.String()) == false {
+        keys = append(keys, key.String())
+      }
+    }
+  }
+
+  if len(keys) == 0 {
+    return reflect.Zero(extraType)
+  }
+
+  result := reflect.MakeMap(extraType)
+
+  for _, key := range keys {
+    var present []mergeCandidate
+
+    for _, candidate := range candidates {
+      if value := candidate.value.MapIndex(reflect.ValueOf(key)); value.IsValid() == true {
+        present = append(present, mergeCandidate{lookup: candidate.lookup, fetchedAt: candidate.fetchedAt, value: value})
+      }
+    }
+
+    chosen := policy.choose(joinFieldPath(path, key), present)
+
+    provenance[joinFieldPath(path, key)] = []string{chosen.lookup}
+    result.SetMapIndex(reflect.ValueOf(key), chosen.value)
+  }
+
+  return result
+}
+
+
+// choose picks a single candidate for a field, as per its strategy ('union' prefers non-nil values on non-slices)
+func (policy *MergePolicy) choose(path string, candidates []mergeCandidate) mergeCandidate {
+  chosen := candidates[0]
+
+  if policy.strategyFor(path) == MergePreferNewest {
+    for _, candidate := range candidates[1:] {
+      if candidate.fetchedAt.After(chosen.fetchedAt) == true {
+        chosen = candidate
+      }
+    }
+  }
+
+  return chosen
+}
+
+
+// strategyFor returns the strategy of a field, inherited from its closest configured parent
+func (policy *MergePolicy) strategyFor(path string) MergeStrategy {
+  for current := path; current != ""; {
+    if strategy, ok := policy.Fields[current]; ok == true {
+      return strategy
+    }
+
+    index := strings.LastIndex(current, ".")
+
+    if index < 0 {
+      break
+    }
+
+    current = current[:index]
+  }
+
+  if policy.Default == "" {
+    return MergePreferNonNil
+  }
+
+  return policy.Default
+}
+
+
+// sameMergeItem returns whether two slice items identify the same entity (by ID, or else by value)
+func sameMergeItem(first reflect.Value, second reflect.Value) bool {
+  if first.Kind() == reflect.Ptr && second.Kind() == reflect.Ptr {
+    if first.IsNil() == true || second.IsNil() == true {
+      return first.IsNil() == second.IsNil()
+    }
+
+    first, second = first.Elem(), second.Elem()
+  }
+
+  switch first.Kind() {
+    case reflect.String:
+      return strings.EqualFold(strings.TrimSpace(first.String()), strings.TrimSpace(second.String()))
+
+    case reflect.Struct:
+      firstID, secondID := first.FieldByName("ID"), second.FieldByName("ID")
+
+      if firstID.IsValid() == true && firstID.Kind() == reflect.Ptr && firstID.IsNil() == false && secondID.IsNil() == false {
+        return reflect.DeepEqual(firstID.Interface(), secondID.Interface())
+      }
+  }
+
+  return reflect.DeepEqual(first.Interface(), second.Interface())
+}
+
+
+// isMergeStruct returns whether a type is a struct (or a pointer to a struct)
+func isMergeStruct(kind reflect.Type) bool {
+  if kind.Kind() == reflect.Ptr {
+    kind = kind.Elem()
+  }
+
+  return kind.Kind() == reflect.Struct
+}
+
+
+// isEmptyMergeValue returns whether a value is unset (nil or zero)
+func isEmptyMergeValue(value reflect.Value) bool {
+  switch value.Kind() {
+    case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+      return value.IsNil()
+  }
+
+  return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+}
+
+
+// validMergeStrategy returns whether a merge strategy is known
+func validMergeStrategy(strategy MergeStrategy) bool {
+  switch strategy {
+    case MergePreferNonNil, MergePreferNewest, MergeUnion:
+      return true
+  }
+
+  return false
+}
@@ -0,0 +1,161 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "net/http"
+  "strconv"
+  "strings"
+  "time"
+)
+
+
+// DiscoveryStatus maps the discovery state of a requested data point
+type DiscoveryStatus string
+
+// Discovery statuses
+const (
+  DiscoveryStatusUnknown DiscoveryStatus = ""
+  DiscoveryStatusCreated DiscoveryStatus = "created"
+  DiscoveryStatusFound DiscoveryStatus = "found"
+)
+
+const (
+  headerRequestID = "X-Request-Id"
+  headerRateLimitLimit = "X-RateLimit-Limit"
+  headerRateLimitRemaining = "X-RateLimit-Remaining"
+  headerRateLimitReset = "X-RateLimit-Reset"
+  headerQuotaLimit = "X-Quota-Limit"
+  headerQuotaRemaining = "X-Quota-Remaining"
+  headerQuotaReset = "X-Quota-Reset"
+  headerServerTiming = "Server-Timing"
+)
+
+
+// ResponseMeta maps metadata carried by an API HTTP response
+type ResponseMeta struct {
+  RequestID     string
+  RateLimit     *ResponseLimit
+  Quota         *ResponseLimit
+  Discovery     DiscoveryStatus
+  ServerTiming  []ServerTiming
+}
+
+// ResponseLimit maps a rate-limit or quota counter
+type ResponseLimit struct {
+  Limit      int64
+  Remaining  int64
+  Reset      time.Time
+}
+
+// ServerTiming maps a metric from the Server-Timing header
+type ServerTiming struct {
+  Name         string
+  Duration     time.Duration
+  Description  string
+}
+
+
+// String returns the string representation of ResponseMeta
+func (instance ResponseMeta) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of ResponseLimit
+func (instance ResponseLimit) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of ServerTiming
+func (instance ServerTiming) String() string {
+  return Stringify(instance)
+}
+
+
+// newResponseMeta extracts metadata from an HTTP response
+func newResponseMeta(httpResponse *http.Response) *ResponseMeta {
+  header := httpResponse.Header
+
+  meta := &ResponseMeta{
+    RequestID: header.Get(headerRequestID),
+    RateLimit: parseResponseLimit(header, headerRateLimitLimit, headerRateLimitRemaining, headerRateLimitReset),
+    Quota: parseResponseLimit(header, headerQuotaLimit, headerQuotaRemaining, headerQuotaReset),
+    Discovery: parseDiscoveryStatus(httpResponse.StatusCode),
+    ServerTiming: parseServerTiming(header.Get(headerServerTiming)),
+  }
+
+  return meta
+}
+
+
+// parseResponseLimit parses a limit counter from headers, if any is set
+func parseResponseLimit(header http.Header, limitKey string, remainingKey string, resetKey string) *ResponseLimit {
+  if header.Get(limitKey) == "" && header.Get(remainingKey) == "" {
+    return nil
+  }
+
+  limit := &ResponseLimit{}
+
+  limit.Limit, _ = strconv.ParseInt(header.Get(limitKey), 10, 64)
+  limit.Remaining, _ = strconv.ParseInt(header.Get(remainingKey), 10, 64)
+
+  if reset, err := strconv.ParseInt(header.Get(resetKey), 10, 64); err == nil {
+    limit.Reset = time.Unix(reset, 0)
+  }
+
+  return limit
+}
+
+
+// parseDiscoveryStatus maps a response status code to a discovery status
+func parseDiscoveryStatus(code int) DiscoveryStatus {
+  switch code {
+    case http.StatusOK:
+      return DiscoveryStatusFound
+
+    case http.StatusCreated:
+      return DiscoveryStatusCreated
+  }
+
+  return DiscoveryStatusUnknown
+}
+
+
+// parseServerTiming parses a Server-Timing header value (eg. 'db;dur=53.2;desc="Database"')
+func parseServerTiming(value string) []ServerTiming {
+  var timings []ServerTiming
+
+  for _, entry := range strings.Split(value, ",") {
+    parts := strings.Split(entry, ";")
+
+    timing := ServerTiming{Name: strings.TrimSpace(parts[0])}
+    if timing.Name == "" {
+      continue
+    }
+
+    for _, param := range parts[1:] {
+      pair := strings.SplitN(strings.TrimSpace(param), "=", 2)
+      if len(pair) != 2 {
+        continue
+      }
+
+      switch strings.ToLower(pair[0]) {
+        case "dur":
+          if duration, err := strconv.ParseFloat(pair[1], 64); err == nil {
+            timing.Duration = time.Duration(duration * float64(time.Millisecond))
+          }
+
+        case "desc":
+          timing.Description = strings.Trim(pair[1], `"`)
+      }
+    }
+
+    timings = append(timings, timing)
+  }
+
+  return timings
+}
@@ -0,0 +1,183 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "fmt"
+  "math"
+  "strings"
+)
+
+
+// EmployeeBuckets lists standard firmographic employee count buckets
+var EmployeeBuckets = []Bucket{
+  {Label: "1-10", Min: 1, Max: 10},
+  {Label: "11-50", Min: 11, Max: 50},
+  {Label: "51-200", Min: 51, Max: 200},
+  {Label: "201-500", Min: 201, Max: 500},
+  {Label: "501-1000", Min: 501, Max: 1000},
+  {Label: "1001-5000", Min: 1001, Max: 5000},
+  {Label: "5001-10000", Min: 5001, Max: 10000},
+  {Label: "10001+", Min: 10001, Max: 0},
+}
+
+// RevenueBuckets lists standard firmographic annual revenue buckets
+var RevenueBuckets = []Bucket{
+  {Label: "0-1M", Min: 0, Max: 1e6 - 1},
+  {Label: "1M-10M", Min: 1e6, Max: 1e7 - 1},
+  {Label: "10M-50M", Min: 1e7, Max: 5e7 - 1},
+  {Label: "50M-100M", Min: 5e7, Max: 1e8 - 1},
+  {Label: "100M-250M", Min: 1e8, Max: 2.5e8 - 1},
+  {Label: "250M-500M", Min: 2.5e8, Max: 5e8 - 1},
+  {Label: "500M-1B", Min: 5e8, Max: 1e9 - 1},
+  {Label: "1B-10B", Min: 1e9, Max: 1e10 - 1},
+  {Label: "10B+", Min: 1e10, Max: 0},
+}
+
+
+// Bucket maps a labelled value range (a zero Max means unbounded)
+type Bucket struct {
+  Label  string  `json:"label"`
+  Min    int64   `json:"min"`
+  Max    int64   `json:"max,omitempty"`
+}
+
+// EmployeeRange maps a company employee count, either exact or as a range (a zero Max means unbounded, unless Exact)
+type EmployeeRange struct {
+  Min    uint32  `json:"min"`
+  Max    uint32  `json:"max,omitempty"`
+  Exact  bool    `json:"exact"`
+}
+
+// CurrencyRates maps ISO currency codes to their value in a common base currency (eg. {"USD": 1, "EUR": 1.08})
+type CurrencyRates map[string]float64
+
+
+// String returns the string representation of Bucket
+func (instance Bucket) String() string {
+  return instance.Label
+}
+
+// String returns the string representation of EmployeeRange
+func (instance EmployeeRange) String() string {
+  return Stringify(instance)
+}
+
+
+// Contains returns whether a value falls in the bucket
+func (instance Bucket) Contains(value int64) bool {
+  return value >= instance.Min && (instance.Max == 0 || value <= instance.Max)
+}
+
+
+// EmployeeRange returns the employee count of the company, if known
+//
+// The API does not document the employees field: the library reads a single value as an exact count, and two values as a [min, max] range, taking a zero max as unbounded.
+func (instance CompanyMetrics) EmployeeRange() (*EmployeeRange, bool) {
+  if instance.Employees == nil || len(*instance.Employees) == 0 {
+    return nil, false
+  }
+
+  employees := *instance.Employees
+
+  if len(employees) == 1 {
+    return &EmployeeRange{Min: employees[0], Max: employees[0], Exact: true}, true
+  }
+
+  employeeRange := &EmployeeRange{Min: employees[0], Max: employees[1]}
+
+  if employeeRange.Max != 0 && employeeRange.Max < employeeRange.Min {
+    employeeRange.Min, employeeRange.Max = employeeRange.Max, employeeRange.Min
+  }
+
+  employeeRange.Exact = employeeRange.Min == employeeRange.Max
+
+  return employeeRange, true
+}
+
+
+// Midpoint returns the middle of the range (or its minimum, if exact or unbounded)
+func (instance EmployeeRange) Midpoint() uint32 {
+  if instance.Exact == true || instance.Max == 0 {
+    return instance.Min
+  }
+
+  return instance.Min + (instance.Max - instance.Min) / 2
+}
+
+
+// Bucket returns the standard employee bucket for the range midpoint, if any (there is none for zero employees)
+func (instance EmployeeRange) Bucket() (Bucket, bool) {
+  return EmployeeBucketFor(int64(instance.Midpoint()))
+}
+
+
+// EmployeeBucketFor returns the standard employee bucket for an employee count
+func EmployeeBucketFor(count int64) (Bucket, bool) {
+  return bucketFor(EmployeeBuckets, count)
+}
+
+
+// RevenueBucketFor returns the standard revenue bucket for an amount
+func RevenueBucketFor(amount int64) (Bucket, bool) {
+  return bucketFor(RevenueBuckets, amount)
+}
+
+
+// Convert converts the annual revenue amount to a target currency, using a rates table
+func (instance CompanyMetricsAnnualRevenue) Convert(target string, rates CurrencyRates) (int64, error) {
+  if instance.Amount == nil {
+    return 0, fmt.Errorf("annual revenue has no amount")
+  }
+  if instance.Currency == nil || *instance.Currency == "" {
+    return 0, fmt.Errorf("annual revenue has no currency")
+  }
+
+  source := strings.ToUpper(*instance.Currency)
+  target = strings.ToUpper(target)
+
+  if source == target {
+    return *instance.Amount, nil
+  }
+
+  sourceRate, ok := rates[source]
+  if ok == false || sourceRate <= 0 {
+    return 0, fmt.Errorf("no rate for currency: %s", source)
+  }
+
+  targetRate, ok := rates[target]
+  if ok == false || targetRate <= 0 {
+    return 0, fmt.Errorf("no rate for currency: %s", target)
+  }
+
+  return int64(math.Round(float64(*instance.Amount) * sourceRate / targetRate)), nil
+}
+
+
+// Bucket returns the standard revenue bucket for the annual revenue, converted to a target currency
+func (instance CompanyMetricsAnnualRevenue) Bucket(target string, rates CurrencyRates) (Bucket, error) {
+  amount, err := instance.Convert(target, rates)
+  if err != nil {
+    return Bucket{}, err
+  }
+
+  bucket, _ := RevenueBucketFor(amount)
+
+  return bucket, nil
+}
+
+
+// bucketFor returns the bucket containing a value
+func bucketFor(buckets []Bucket, value int64) (Bucket, bool) {
+  for _, bucket := range buckets {
+    if bucket.Contains(value) == true {
+      return bucket, true
+    }
+  }
+
+  return Bucket{}, false
+}
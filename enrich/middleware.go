@@ -0,0 +1,397 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "context"
+  "errors"
+  "net"
+  "net/http"
+  "strings"
+  "sync"
+  "time"
+)
+
+
+const (
+  defaultMiddlewareTimeout = 250 * time.Millisecond
+  defaultMiddlewareLookupTimeout = 5 * time.Second
+  defaultMiddlewareConcurrency = 64
+)
+
+
+// ErrNetworkLookupBusy is returned when too many network lookups are in flight, and the request was let through unenriched
+var ErrNetworkLookupBusy = errors.New("too many network lookups in flight")
+
+
+// NetworkMiddlewareOptions mapping
+type NetworkMiddlewareOptions struct {
+  TrustedProxies  []string
+  Timeout         time.Duration
+  Async           bool
+  Policy          *NetworkPolicy
+  Country         func(req *http.Request) string
+  BlockHandler    http.Handler
+  LookupTimeout   time.Duration
+  MaxConcurrency  int
+  CacheTTL        time.Duration
+  CacheSize       int
+}
+
+// RequestNetwork maps the network enrichment of an incoming request, which may still be pending
+type RequestNetwork struct {
+  IP  string
+
+  lookup    *networkLookup
+  policy    *NetworkPolicy
+  country   string
+  riskOnce  sync.Once
+  risk      *NetworkRisk
+}
+
+// networkLookup maps a network lookup, shared by all requests from its IP while in flight, then cached for that IP
+type networkLookup struct {
+  done  chan struct{}
+  data  *EnrichNetworkData
+  err   error
+}
+
+// networkMiddleware maps a network enrichment middleware
+type networkMiddleware struct {
+  client   *Client
+  options  NetworkMiddlewareOptions
+  proxies  []*net.IPNet
+  cache    *blockCache
+  slots    chan struct{}
+
+  lock     sync.Mutex
+  flights  map[string]*networkLookup
+}
+
+type requestNetworkContextKey struct{}
+
+
+// NewNetworkMiddleware returns a net/http middleware enriching the network of incoming requests
+//
+// The client IP is read from the Forwarded or X-Forwarded-For headers when the request comes from a trusted proxy (IPs or CIDR blocks), otherwise from the connection. The enrichment is stored in the request context, see RequestNetworkFromContext. Handlers are called once the enrichment is done or the timeout is elapsed (whichever comes first), or right away in async mode. If a policy is set, requests evaluated as high risk within the timeout are passed to the block handler (403 by default); async requests are never blocked.
+//
+// Enrichments are cached per IP, and concurrent requests from the same IP share a single lookup. Each lookup is cancelled after the lookup timeout (5 seconds by default), regardless of the request. At most MaxConcurrency lookups (64 by default) are in flight at once; past that, requests are let through unenriched with ErrNetworkLookupBusy rather than queued.
+func NewNetworkMiddleware(client *Client, options NetworkMiddlewareOptions) (func(http.Handler) http.Handler, error) {
+  if options.Timeout <= 0 {
+    options.Timeout = defaultMiddlewareTimeout
+  }
+  if options.LookupTimeout <= 0 {
+    options.LookupTimeout = defaultMiddlewareLookupTimeout
+  }
+  if options.MaxConcurrency <= 0 {
+    options.MaxConcurrency = defaultMiddlewareConcurrency
+  }
+  if options.CacheTTL <= 0 {
+    options.CacheTTL = defaultAttributionCacheTTL
+  }
+  if options.CacheSize <= 0 {
+    options.CacheSize = defaultAttributionCacheSize
+  }
+  if options.BlockHandler == nil {
+    options.BlockHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+      http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+    })
+  }
+
+  middleware := &networkMiddleware{
+    client: client,
+    options: options,
+    cache: newBlockCache(),
+    slots: make(chan struct{}, options.MaxConcurrency),
+    flights: make(map[string]*networkLookup),
+  }
+
+  for _, proxy := range options.TrustedProxies {
+    block, err := parseGraphBlock(proxy)
+    if err != nil {
+      return nil, err
+    }
+
+    middleware.proxies = append(middleware.proxies, block)
+  }
+
+  return middleware.wrap, nil
+}
+
+
+// RequestNetworkFromContext returns the network enrichment of a request, if any
+func RequestNetworkFromContext(ctx context.Context) *RequestNetwork {
+  network, _ := ctx.Value(requestNetworkContextKey{}).(*RequestNetwork)
+
+  return network
+}
+
+
+// Done returns a channel closed once the enrichment is done
+func (network *RequestNetwork) Done() <-chan struct{} {
+  return network.lookup.done
+}
+
+
+// Data returns the enriched network, or nil if pending or failed
+//
+// The data may be shared with other requests, thus must not be modified.
+func (network *RequestNetwork) Data() *EnrichNetworkData {
+  if network.ready() == false {
+    return nil
+  }
+
+  return network.lookup.data
+}
+
+
+// Risk returns the risk evaluation of the network, or nil if pending, failed or no policy is set
+func (network *RequestNetwork) Risk() *NetworkRisk {
+  if network.ready() == false || network.lookup.err != nil || network.policy == nil {
+    return nil
+  }
+
+  network.riskOnce.Do(func() {
+    network.risk = network.policy.Evaluate(network.lookup.data, network.country)
+  })
+
+  return network.risk
+}
+
+
+// Err returns the enrichment error, if any (nil if pending)
+func (network *RequestNetwork) Err() error {
+  if network.ready() == false {
+    return nil
+  }
+
+  return network.lookup.err
+}
+
+
+// ready returns whether the enrichment is done
+func (network *RequestNetwork) ready() bool {
+  select {
+    case <-network.lookup.done:
+      return true
+
+    default:
+      return false
+  }
+}
+
+
+// wrap wraps a handler, enriching the network of each request
+func (middleware *networkMiddleware) wrap(next http.Handler) http.Handler {
+  return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+    ip := middleware.clientIP(req)
+
+    if ip == nil {
+      next.ServeHTTP(w, req)
+
+      return
+    }
+
+    network := &RequestNetwork{IP: ip.String(), policy: middleware.options.Policy}
+
+    if middleware.options.Country != nil {
+      network.country = middleware.options.Country(req)
+    }
+
+    // Private and reserved IPs cannot be enriched
+    if isAttributionBogon(ip) == true {
+      network.lookup = newNetworkLookupDone(nil)
+    } else {
+      network.lookup = middleware.lookup(ip)
+    }
+
+    req = req.WithContext(context.WithValue(req.Context(), requestNetworkContextKey{}, network))
+
+    if middleware.options.Async == false {
+      timer := time.NewTimer(middleware.options.Timeout)
+
+      select {
+        case <-network.lookup.done:
+          timer.Stop()
+
+        case <-timer.C:
+      }
+
+      if risk := network.Risk(); risk != nil && risk.Level == NetworkRiskHigh {
+        middleware.options.BlockHandler.ServeHTTP(w, req)
+
+        return
+      }
+    }
+
+    next.ServeHTTP(w, req)
+  })
+}
+
+
+// lookup returns the cached or in-flight lookup of an IP, or else starts a new one if a slot is free
+func (middleware *networkMiddleware) lookup(ip net.IP) *networkLookup {
+  if cached, ok := middleware.cache.get(ip); ok == true {
+    return cached.(*networkLookup)
+  }
+
+  middleware.lock.Lock()
+  defer middleware.lock.Unlock()
+
+  key := ip.String()
+
+  if lookup, ok := middleware.flights[key]; ok == true {
+    return lookup
+  }
+
+  // The lookup may have completed since the cache was checked
+  if cached, ok := middleware.cache.get(ip); ok == true {
+    return cached.(*networkLookup)
+  }
+
+  // Fail open rather than queueing requests behind a slow API
+  select {
+    case middleware.slots <- struct{}{}:
+
+    default:
+      return newNetworkLookupDone(ErrNetworkLookupBusy)
+  }
+
+  lookup := &networkLookup{done: make(chan struct{})}
+
+  middleware.flights[key] = lookup
+
+  go middleware.enrich(ip, lookup)
+
+  return lookup
+}
+
+
+// enrich enriches the network of an IP within the lookup timeout, and caches the result for that IP
+func (middleware *networkMiddleware) enrich(ip net.IP, lookup *networkLookup) {
+  defer func() {
+    <-middleware.slots
+  }()
+
+  ctx, cancel := context.WithTimeout(context.Background(), middleware.options.LookupTimeout)
+  defer cancel()
+
+  lookup.data, _, lookup.err = middleware.client.Enrich.enrichNetworkBy(ctx, "ip", ip.String())
+
+  // Enrichments hold per-IP data (geolocation, usage), thus are never shared across a block. Networks unknown to the API are cached as well, while transient failures are retried on the next request.
+  if responseErr, ok := lookup.err.(*ResponseError); lookup.err == nil || (ok == true && responseErr.Reason == "not_found") {
+    middleware.cache.put(attributionBlock(ip, nil), lookup, middleware.options.CacheTTL, middleware.options.CacheSize)
+  }
+
+  middleware.lock.Lock()
+  delete(middleware.flights, ip.String())
+  middleware.lock.Unlock()
+
+  close(lookup.done)
+}
+
+
+// newNetworkLookupDone returns a lookup done without any enrichment
+func newNetworkLookupDone(err error) *networkLookup {
+  lookup := &networkLookup{done: make(chan struct{}), err: err}
+
+  close(lookup.done)
+
+  return lookup
+}
+
+
+// clientIP returns the IP of the client, walking forwarding headers back from trusted proxies
+func (middleware *networkMiddleware) clientIP(req *http.Request) net.IP {
+  ip := parseForwardedIP(req.RemoteAddr)
+
+  if ip == nil || middleware.trusted(ip) == false {
+    return ip
+  }
+
+  // The closest hop is last, thus the client is the first untrusted hop from the end
+  hops := forwardedHops(req.Header)
+
+  for i := len(hops) - 1; i >= 0; i-- {
+    hop := parseForwardedIP(hops[i])
+
+    // Obfuscated or unknown hops cannot be walked past
+    if hop == nil {
+      break
+    }
+
+    ip = hop
+
+    if middleware.trusted(hop) == false {
+      break
+    }
+  }
+
+  return ip
+}
+
+
+// trusted returns whether an IP is a trusted proxy
+func (middleware *networkMiddleware) trusted(ip net.IP) bool {
+  for _, proxy := range middleware.proxies {
+    if proxy.Contains(ip) == true {
+      return true
+    }
+  }
+
+  return false
+}
+
+
+// forwardedHops returns the hops listed in the Forwarded header (RFC 7239), or else in the X-Forwarded-For header
+func forwardedHops(header http.Header) []string {
+  var hops []string
+
+  if values := header["Forwarded"]; len(values) > 0 {
+    for _, element := range strings.Split(strings.Join(values, ","), ",") {
+      hop := ""
+
+      for _, pair := range strings.Split(element, ";") {
+        parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+
+        if len(parts) == 2 && strings.EqualFold(parts[0], "for") == true {
+          hop = strings.Trim(parts[1], "\"")
+        }
+      }
+
+      hops = append(hops, hop)
+    }
+
+    return hops
+  }
+
+  for _, value := range header["X-Forwarded-For"] {
+    for _, hop := range strings.Split(value, ",") {
+      hops = append(hops, hop)
+    }
+  }
+
+  return hops
+}
+
+
+// parseForwardedIP parses an IP from a remote address or a forwarded hop, which may hold a port
+func parseForwardedIP(value string) net.IP {
+  value = strings.TrimSpace(value)
+
+  if host, _, err := net.SplitHostPort(value); err == nil {
+    value = host
+  }
+
+  ip := net.ParseIP(strings.Trim(value, "[]"))
+
+  if ip4 := ip.To4(); ip4 != nil {
+    return ip4
+  }
+
+  return ip
+}
@@ -0,0 +1,245 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "encoding/json"
+  "fmt"
+  "io"
+  "io/ioutil"
+  "sort"
+  "strings"
+)
+
+
+// NetworkRiskLevel maps the risk level of a network evaluation
+type NetworkRiskLevel string
+
+// Network risk levels
+const (
+  NetworkRiskLow NetworkRiskLevel = "low"
+  NetworkRiskMedium NetworkRiskLevel = "medium"
+  NetworkRiskHigh NetworkRiskLevel = "high"
+  NetworkRiskUnknown NetworkRiskLevel = "unknown"
+)
+
+const networkSignalCountryMismatch = "country_mismatch"
+
+// networkRiskScoreMax caps the risk score of networks, weights adding up from zero (higher is riskier)
+const networkRiskScoreMax = 100
+
+// networkSignals lists all signals that can be raised from a network enrichment
+var networkSignals = []networkSignal{
+  {"tor", "Traffic comes from a TOR exit node", func(network *Network) *bool { return networkUsage(network, func(usage *NetworkUsage) *bool { return usage.TOR }) }},
+  {"vpn", "Traffic comes from a VPN", func(network *Network) *bool { return networkUsage(network, func(usage *NetworkUsage) *bool { return usage.VPN }) }},
+  {"server", "Traffic comes from a server or hosting provider", func(network *Network) *bool { return networkUsage(network, func(usage *NetworkUsage) *bool { return usage.Server }) }},
+  {"mobile", "Traffic comes from a mobile network", func(network *Network) *bool { return networkUsage(network, func(usage *NetworkUsage) *bool { return usage.Mobile }) }},
+  {"reverse_mismatch", "Reverse DNS does not match the IP", func(network *Network) *bool {
+    if network.Reverse == nil {
+      return nil
+    }
+
+    return negateBool(network.Reverse.Matches)
+  }},
+  {"host_reachable", "Host answers to inbound connections", func(network *Network) *bool {
+    if network.Host == nil {
+      return nil
+    }
+
+    return network.Host.Reachable
+  }},
+}
+
+
+type networkSignal struct {
+  name     string
+  reason   string
+  extract  func(network *Network) *bool
+}
+
+// NetworkPolicy maps a configurable network risk policy
+type NetworkPolicy struct {
+  Weights      map[string]float32  `json:"weights,omitempty"`
+  Block        []string            `json:"block,omitempty"`
+  MediumScore  float32             `json:"medium_score"`
+  HighScore    float32             `json:"high_score"`
+}
+
+// NetworkRisk maps the result of a network risk evaluation
+type NetworkRisk struct {
+  Level    NetworkRiskLevel  `json:"level"`
+  Score    float32           `json:"score"`
+  Signals  []string          `json:"signals,omitempty"`
+  Reasons  []string          `json:"reasons,omitempty"`
+}
+
+
+// String returns the string representation of NetworkPolicy
+func (instance NetworkPolicy) String() string {
+  return Stringify(instance)
+}
+
+// String returns the string representation of NetworkRisk
+func (instance NetworkRisk) String() string {
+  return Stringify(instance)
+}
+
+
+// NewNetworkPolicy returns the default network policy
+func NewNetworkPolicy() *NetworkPolicy {
+  return &NetworkPolicy{
+    Weights: map[string]float32{
+      "tor": 80,
+      "vpn": 40,
+      "server": 40,
+      "mobile": 0,
+      "reverse_mismatch": 10,
+      "host_reachable": 10,
+      networkSignalCountryMismatch: 30,
+    },
+    Block: []string{"tor"},
+    MediumScore: 30,
+    HighScore: 60,
+  }
+}
+
+
+// ParseNetworkPolicy parses a JSON network policy, applied over the policy returned by NewNetworkPolicy
+//
+// Thresholds present in the JSON policy replace the defaults, eg. a medium score of 0 flags any network as medium risk at least. A block list replaces the default one (which blocks Tor), and weights are set per signal on top of the default weights.
+func ParseNetworkPolicy(data []byte) (*NetworkPolicy, error) {
+  policy := NewNetworkPolicy()
+
+  // Network policies have no preset, thus always start from the defaults
+  if err := json.Unmarshal(data, policy); err != nil {
+    return nil, err
+  }
+
+  if err := policy.Validate(); err != nil {
+    return nil, err
+  }
+
+  return policy, nil
+}
+
+
+// LoadNetworkPolicy reads and parses a JSON network policy
+func LoadNetworkPolicy(reader io.Reader) (*NetworkPolicy, error) {
+  data, err := ioutil.ReadAll(reader)
+  if err != nil {
+    return nil, err
+  }
+
+  return ParseNetworkPolicy(data)
+}
+
+
+// Validate checks that a policy only refers to known signals
+func (policy *NetworkPolicy) Validate() error {
+  for name := range policy.Weights {
+    if isNetworkSignal(name) == false {
+      return fmt.Errorf("unknown network signal in weights: %q", name)
+    }
+  }
+
+  for _, name := range policy.Block {
+    if isNetworkSignal(name) == false {
+      return fmt.Errorf("unknown network signal in block: %q", name)
+    }
+  }
+
+  if policy.MediumScore > policy.HighScore {
+    return fmt.Errorf("medium score (%v) is greater than high score (%v)", policy.MediumScore, policy.HighScore)
+  }
+
+  return nil
+}
+
+
+// Evaluate assesses the risk of a network, optionally checking it against an expected country (which may be empty)
+func (policy *NetworkPolicy) Evaluate(data *EnrichNetworkData, expectedCountry string) *NetworkRisk {
+  risk := &NetworkRisk{Level: NetworkRiskUnknown}
+
+  if data == nil || data.Network == nil {
+    risk.Reasons = []string{"Network could not be enriched"}
+
+    return risk
+  }
+
+  blocked := false
+  score := float32(0)
+
+  raise := func(name string, reason string) {
+    risk.Signals = append(risk.Signals, name)
+    risk.Reasons = append(risk.Reasons, reason)
+
+    score += policy.Weights[name]
+
+    if containsString(policy.Block, name) == true {
+      blocked = true
+    }
+  }
+
+  for _, signal := range networkSignals {
+    if value := signal.extract(data.Network); value != nil && *value == true {
+      raise(signal.name, signal.reason)
+    }
+  }
+
+  if expectedCountry != "" && data.Network.Geolocation != nil && data.Network.Geolocation.Country != nil {
+    country := *data.Network.Geolocation.Country
+
+    if strings.EqualFold(country, expectedCountry) == false {
+      raise(networkSignalCountryMismatch, fmt.Sprintf("Traffic comes from %s, while %s was expected", country, expectedCountry))
+    }
+  }
+
+  risk.Score = clampScore(score, networkRiskScoreMax)
+
+  switch {
+    case blocked == true || risk.Score >= policy.HighScore:
+      risk.Level = NetworkRiskHigh
+
+    case risk.Score >= policy.MediumScore:
+      risk.Level = NetworkRiskMedium
+
+    default:
+      risk.Level = NetworkRiskLow
+  }
+
+  return risk
+}
+
+
+// NetworkSignals returns the names of all signals usable in network policies
+func NetworkSignals() []string {
+  names := []string{networkSignalCountryMismatch}
+
+  for _, signal := range networkSignals {
+    names = append(names, signal.name)
+  }
+
+  sort.Strings(names)
+
+  return names
+}
+
+
+// isNetworkSignal returns whether a signal name is known
+func isNetworkSignal(name string) bool {
+  return containsString(NetworkSignals(), name)
+}
+
+
+// networkUsage extracts a usage flag from a network
+func networkUsage(network *Network, extract func(usage *NetworkUsage) *bool) *bool {
+  if network.Usage == nil {
+    return nil
+  }
+
+  return extract(network.Usage)
+}
@@ -0,0 +1,346 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "fmt"
+  "strings"
+)
+
+
+// PhoneType maps the type of a phone number
+type PhoneType string
+
+// Phone types
+const (
+  PhoneTypeUnknown PhoneType = "unknown"
+  PhoneTypeMobile PhoneType = "mobile"
+  PhoneTypeFixedLine PhoneType = "fixed_line"
+  PhoneTypeTollFree PhoneType = "toll_free"
+)
+
+const (
+  phoneMinDigits = 6
+  phoneMaxDigits = 15
+)
+
+
+// PhoneNumber maps a normalized phone number
+type PhoneNumber struct {
+  Raw          string     `json:"raw"`
+  E164         string     `json:"e164,omitempty"`
+  Country      string     `json:"country,omitempty"`
+  CallingCode  string     `json:"calling_code,omitempty"`
+  National     string     `json:"national,omitempty"`
+  Extension    string     `json:"extension,omitempty"`
+  Type         PhoneType  `json:"type"`
+  Valid        bool       `json:"valid"`
+  Reason       string     `json:"reason,omitempty"`
+}
+
+// PhoneError maps a phone number that could not be normalized
+type PhoneError struct {
+  Raw     string
+  Reason  string
+}
+
+// phoneRegion maps the numbering plan metadata of a country
+type phoneRegion struct {
+  country   string
+  names     []string
+  code      string
+  trunk     string
+  lengths   []int
+  mobile    []string
+  tollFree  []string
+  fixed     bool
+}
+
+
+// phoneRegions embeds offline numbering plan metadata for major countries
+//
+// Prefixes are matched on national significant numbers. When 'fixed' is set, numbers which are neither mobile nor toll-free are classified as fixed lines.
+var phoneRegions = []phoneRegion{
+  {"US", []string{"united states", "usa"}, "1", "1", []int{10}, nil, []string{"800", "833", "844", "855", "866", "877", "888"}, false},
+  {"CA", []string{"canada"}, "1", "1", []int{10}, nil, []string{"800", "833", "844", "855", "866", "877", "888"}, false},
+  {"GB", []string{"united kingdom", "great britain", "uk"}, "44", "0", []int{9, 10}, []string{"71", "72", "73", "74", "75", "77", "78", "79"}, []string{"800", "808"}, true},
+  {"FR", []string{"france"}, "33", "0", []int{9}, []string{"6", "7"}, []string{"80"}, true},
+  {"DE", []string{"germany", "deutschland"}, "49", "0", []int{6, 7, 8, 9, 10, 11}, []string{"15", "16", "17"}, []string{"800"}, true},
+  {"ES", []string{"spain", "espana"}, "34", "", []int{9}, []string{"6", "7"}, []string{"800", "900"}, true},
+  {"IT", []string{"italy", "italia"}, "39", "", []int{6, 7, 8, 9, 10, 11}, []string{"3"}, []string{"800", "803"}, true},
+  {"NL", []string{"netherlands", "the netherlands"}, "31", "0", []int{9}, []string{"6"}, []string{"800"}, true},
+  {"BE", []string{"belgium"}, "32", "0", []int{8, 9}, []string{"45", "46", "47", "48", "49"}, []string{"800"}, true},
+  {"CH", []string{"switzerland"}, "41", "0", []int{9}, []string{"75", "76", "77", "78", "79"}, []string{"800"}, true},
+  {"AT", []string{"austria"}, "43", "0", []int{4, 5, 6, 7, 8, 9, 10, 11, 12, 13}, []string{"65", "66", "67", "68", "69"}, []string{"800"}, true},
+  {"IE", []string{"ireland"}, "353", "0", []int{7, 8, 9}, []string{"83", "85", "86", "87", "89"}, []string{"1800"}, true},
+  {"PT", []string{"portugal"}, "351", "", []int{9}, []string{"9"}, []string{"800"}, true},
+  {"SE", []string{"sweden"}, "46", "0", []int{7, 8, 9}, []string{"70", "72", "73", "76", "79"}, []string{"20"}, true},
+  {"NO", []string{"norway"}, "47", "", []int{8}, []string{"4", "9"}, []string{"800"}, true},
+  {"DK", []string{"denmark"}, "45", "", []int{8}, nil, []string{"80"}, false},
+  {"FI", []string{"finland"}, "358", "0", []int{5, 6, 7, 8, 9, 10}, []string{"4", "50"}, []string{"800"}, true},
+  {"PL", []string{"poland"}, "48", "", []int{9}, []string{"45", "50", "51", "53", "57", "60", "66", "69", "72", "73", "78", "79", "88"}, []string{"800"}, true},
+  {"RU", []string{"russia", "russian federation"}, "7", "8", []int{10}, []string{"9"}, []string{"800"}, true},
+  {"IL", []string{"israel"}, "972", "0", []int{8, 9}, []string{"5"}, []string{"1800"}, true},
+  {"AE", []string{"united arab emirates", "uae"}, "971", "0", []int{8, 9}, []string{"5"}, []string{"800"}, true},
+  {"ZA", []string{"south africa"}, "27", "0", []int{9}, []string{"6", "7", "8"}, []string{"80"}, true},
+  {"IN", []string{"india"}, "91", "0", []int{10}, []string{"6", "7", "8", "9"}, []string{"1800"}, true},
+  {"CN", []string{"china"}, "86", "0", []int{10, 11}, []string{"13", "14", "15", "16", "17", "18", "19"}, []string{"400", "800"}, true},
+  {"JP", []string{"japan"}, "81", "0", []int{9, 10}, []string{"70", "80", "90"}, []string{"120", "800"}, true},
+  {"KR", []string{"south korea", "korea"}, "82", "0", []int{8, 9, 10}, []string{"10"}, []string{"80"}, true},
+  {"SG", []string{"singapore"}, "65", "", []int{8}, []string{"8", "9"}, []string{"800"}, true},
+  {"HK", []string{"hong kong"}, "852", "", []int{8}, []string{"5", "6", "9"}, []string{"800"}, true},
+  {"AU", []string{"australia"}, "61", "0", []int{9}, []string{"4"}, []string{"1800"}, true},
+  {"NZ", []string{"new zealand"}, "64", "0", []int{8, 9, 10}, []string{"2"}, []string{"800"}, true},
+  {"BR", []string{"brazil", "brasil"}, "55", "0", []int{10, 11}, nil, []string{"800"}, false},
+  {"MX", []string{"mexico"}, "52", "", []int{10}, nil, []string{"800"}, false},
+  {"AR", []string{"argentina"}, "54", "0", []int{10}, []string{"9"}, []string{"800"}, false},
+}
+
+
+// String returns the string representation of PhoneNumber
+func (instance PhoneNumber) String() string {
+  if instance.Valid == true {
+    return instance.E164
+  }
+
+  return instance.Raw
+}
+
+
+// Error prints a phone error
+func (err *PhoneError) Error() string {
+  return fmt.Sprintf("invalid_phone Phone number %q could not be normalized: %s", err.Raw, err.Reason)
+}
+
+
+// ParsePhone normalizes a phone number to E.164, using a default region (ISO code or country name) for national numbers
+func ParsePhone(raw string, defaultRegion string) (*PhoneNumber, error) {
+  number := &PhoneNumber{Raw: raw, Type: PhoneTypeUnknown}
+  region := lookupPhoneRegion(defaultRegion)
+
+  digits, extension, international := splitPhone(raw)
+  number.Extension = extension
+
+  // International call prefixes (eg. '00' in most countries, '011' in North America)
+  if international == false {
+    if strings.HasPrefix(digits, "00") == true {
+      digits, international = digits[2:], true
+    } else if region != nil && region.code == "1" && strings.HasPrefix(digits, "011") == true {
+      digits, international = digits[3:], true
+    }
+  }
+
+  if len(digits) < phoneMinDigits {
+    return phoneFailure(number, "too few digits")
+  }
+
+  var national string
+
+  if international == true {
+    region, national = matchPhoneCallingCode(digits, region)
+
+    if region == nil {
+      // Unknown calling code: only the length can be checked
+      if len(digits) > phoneMaxDigits {
+        return phoneFailure(number, "too many digits")
+      }
+
+      number.E164 = "+" + digits
+      number.Valid = true
+
+      return number, nil
+    }
+  } else {
+    if region == nil {
+      return phoneFailure(number, "national number without a known region")
+    }
+
+    national = digits
+  }
+
+  // Strip the trunk prefix, unless it is a legit first digit (international numbers may also carry it, eg. '+33 (0)6...')
+  if region.trunk != "" && strings.HasPrefix(national, region.trunk) == true {
+    if containsInt(region.lengths, len(national) - len(region.trunk)) == true || containsInt(region.lengths, len(national)) == false {
+      national = national[len(region.trunk):]
+    }
+  }
+
+  if containsInt(region.lengths, len(national)) == false {
+    return phoneFailure(number, fmt.Sprintf("invalid length for %s", region.country))
+  }
+
+  number.Country = region.country
+  number.CallingCode = region.code
+  number.National = national
+  number.E164 = "+" + region.code + national
+  number.Type = region.classify(national)
+  number.Valid = true
+
+  return number, nil
+}
+
+
+// NormalizedPhones returns the normalized phones of the person (using its country as default region)
+func (instance Person) NormalizedPhones() []PhoneNumber {
+  var region string
+
+  if instance.Address != nil && instance.Address.Country != nil {
+    region = *instance.Address.Country
+  } else if instance.Geolocation != nil && instance.Geolocation.Country != nil {
+    region = *instance.Geolocation.Country
+  }
+
+  return normalizePhones(instance.Contact, region)
+}
+
+// NormalizedPhones returns the normalized phones of the company (using its country as default region)
+func (instance Company) NormalizedPhones() []PhoneNumber {
+  var region string
+
+  if instance.Address != nil && instance.Address.Country != nil {
+    region = *instance.Address.Country
+  }
+
+  return normalizePhones(instance.Contact, region)
+}
+
+
+// normalizePhones normalizes all phones of a contact, flagging the invalid ones
+func normalizePhones(contact *Contact, region string) []PhoneNumber {
+  var numbers []PhoneNumber
+
+  if contact == nil || contact.Phones == nil {
+    return nil
+  }
+
+  for _, raw := range *contact.Phones {
+    // Invalid numbers are kept, flagged with the reason why they could not be normalized
+    number, _ := ParsePhone(raw, region)
+
+    numbers = append(numbers, *number)
+  }
+
+  return numbers
+}
+
+
+// classify returns the type of a national significant number
+func (region *phoneRegion) classify(national string) PhoneType {
+  if hasAnyPrefix(national, region.tollFree) == true {
+    return PhoneTypeTollFree
+  }
+  if hasAnyPrefix(national, region.mobile) == true {
+    return PhoneTypeMobile
+  }
+  if region.fixed == true {
+    return PhoneTypeFixedLine
+  }
+
+  return PhoneTypeUnknown
+}
+
+
+// splitPhone extracts digits and extension from a raw phone number, and whether it is international
+func splitPhone(raw string) (string, string, bool) {
+  lower := strings.ToLower(raw)
+  extension := ""
+
+  for _, marker := range []string{"extension", "ext.", "ext", "x", "#"} {
+    if index := strings.Index(lower, marker); index > 0 {
+      extension = keepDigits(lower[index + len(marker):])
+      lower = lower[:index]
+      break
+    }
+  }
+
+  trimmed := strings.TrimSpace(lower)
+
+  return keepDigits(trimmed), extension, strings.HasPrefix(trimmed, "+")
+}
+
+
+// matchPhoneCallingCode finds the region of an international number, preferring the default region on shared codes
+func matchPhoneCallingCode(digits string, preferred *phoneRegion) (*phoneRegion, string) {
+  if preferred != nil && strings.HasPrefix(digits, preferred.code) == true {
+    return preferred, digits[len(preferred.code):]
+  }
+
+  // Calling codes are prefix-free, thus the first match is the only one
+  for length := 1; length <= 3 && length < len(digits); length++ {
+    for i := range phoneRegions {
+      if phoneRegions[i].code == digits[:length] {
+        return &phoneRegions[i], digits[length:]
+      }
+    }
+  }
+
+  return nil, ""
+}
+
+
+// lookupPhoneRegion returns the region for an ISO country code or country name
+func lookupPhoneRegion(country string) *phoneRegion {
+  country = strings.ToLower(strings.TrimSpace(country))
+
+  if country == "" {
+    return nil
+  }
+
+  for i := range phoneRegions {
+    if strings.ToLower(phoneRegions[i].country) == country || containsString(phoneRegions[i].names, country) == true {
+      return &phoneRegions[i]
+    }
+  }
+
+  return nil
+}
+
+
+// phoneFailure returns a phone number flagged as invalid, along with its error
+func phoneFailure(number *PhoneNumber, reason string) (*PhoneNumber, error) {
+  number.Reason = reason
+
+  return number, &PhoneError{Raw: number.Raw, Reason: reason}
+}
+
+
+// keepDigits strips all non-digit characters
+func keepDigits(value string) string {
+  var digits strings.Builder
+
+  for _, character := range value {
+    if character >= '0' && character <= '9' {
+      digits.WriteRune(character)
+    }
+  }
+
+  return digits.String()
+}
+
+
+// hasAnyPrefix returns whether a value starts with any prefix
+func hasAnyPrefix(value string, prefixes []string) bool {
+  for _, prefix := range prefixes {
+    if strings.HasPrefix(value, prefix) == true {
+      return true
+    }
+  }
+
+  return false
+}
+
+
+// containsInt returns whether an integer is in a list
+func containsInt(values []int, value int) bool {
+  for _, current := range values {
+    if current == value {
+      return true
+    }
+  }
+
+  return false
+}
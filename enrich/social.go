@@ -0,0 +1,425 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "net/url"
+  "sort"
+  "strings"
+)
+
+
+// Social networks
+const (
+  SocialNetworkFacebook = "facebook"
+  SocialNetworkTwitter = "twitter"
+  SocialNetworkLinkedIn = "linkedin"
+  SocialNetworkGitHub = "github"
+  SocialNetworkYouTube = "youtube"
+  SocialNetworkInstagram = "instagram"
+)
+
+// socialHosts maps known hosts (including legacy and short domains) to their network
+var socialHosts = map[string]string{
+  "facebook.com": SocialNetworkFacebook,
+  "fb.com": SocialNetworkFacebook,
+  "fb.me": SocialNetworkFacebook,
+  "twitter.com": SocialNetworkTwitter,
+  "x.com": SocialNetworkTwitter,
+  "linkedin.com": SocialNetworkLinkedIn,
+  "github.com": SocialNetworkGitHub,
+  "youtube.com": SocialNetworkYouTube,
+  "instagram.com": SocialNetworkInstagram,
+  "instagr.am": SocialNetworkInstagram,
+}
+
+// socialReservedPaths lists paths which are not profiles, for each network
+var socialReservedPaths = map[string][]string{
+  SocialNetworkFacebook: {"sharer", "sharer.php", "share.php", "dialog", "groups", "events", "watch", "login", "home.php"},
+  SocialNetworkTwitter: {"intent", "share", "home", "i", "search", "hashtag", "login"},
+  SocialNetworkGitHub: {"orgs", "settings", "login", "about", "features"},
+  SocialNetworkInstagram: {"p", "explore", "accounts", "reel", "stories"},
+  SocialNetworkYouTube: {"watch", "playlist", "results", "feed", "shorts"},
+}
+
+
+// SocialProfile maps a canonical social profile
+type SocialProfile struct {
+  Network  string  `json:"network"`
+  Handle   string  `json:"handle,omitempty"`
+  ID       string  `json:"id,omitempty"`
+  URL      string  `json:"url"`
+}
+
+
+// String returns the string representation of SocialProfile
+func (instance SocialProfile) String() string {
+  return instance.URL
+}
+
+
+// CanonicalizeSocial turns a handle, an URL or an ID into a canonical social profile
+//
+// The network may be empty if the value is an URL, in which case it is guessed from its host. Bare handles and IDs are taken as personal profiles, see CanonicalizeCompanySocial for company pages.
+func CanonicalizeSocial(network string, value string) (SocialProfile, bool) {
+  return canonicalizeSocial(network, value, false)
+}
+
+
+// CanonicalizeCompanySocial turns a handle, an URL or an ID into a canonical social profile, taking bare handles and IDs as company pages
+func CanonicalizeCompanySocial(network string, value string) (SocialProfile, bool) {
+  return canonicalizeSocial(network, value, true)
+}
+
+
+// canonicalizeSocial canonicalizes a social profile, of a company or a person
+func canonicalizeSocial(network string, value string, company bool) (SocialProfile, bool) {
+  value = strings.TrimSpace(value)
+  network = strings.ToLower(strings.TrimSpace(network))
+
+  if value == "" {
+    return SocialProfile{}, false
+  }
+
+  if looksLikeURL(value) == true {
+    return canonicalizeSocialURL(network, value, company)
+  }
+
+  if network == "" {
+    return SocialProfile{}, false
+  }
+
+  return canonicalizeSocialHandle(network, value, company)
+}
+
+
+// SocialProfiles returns the canonical social profiles of the person, merged from its social networks and contact
+func (instance Person) SocialProfiles() []SocialProfile {
+  var profiles []SocialProfile
+
+  if instance.Social != nil {
+    for network, social := range map[string]*PersonSocialNetwork{
+      SocialNetworkFacebook: instance.Social.Facebook,
+      SocialNetworkTwitter: instance.Social.Twitter,
+      SocialNetworkLinkedIn: instance.Social.LinkedIn,
+      SocialNetworkGitHub: instance.Social.GitHub,
+      SocialNetworkYouTube: instance.Social.YouTube,
+      SocialNetworkInstagram: instance.Social.Instagram,
+    } {
+      if social == nil {
+        continue
+      }
+
+      // Prefer the URL, as it is unambiguous (eg. YouTube channels vs. users)
+      if social.URL != nil {
+        profiles = appendSocialProfile(profiles, network, *social.URL, false)
+      }
+      if social.Handle != nil {
+        profiles = appendSocialProfile(profiles, network, *social.Handle, false)
+      }
+    }
+  }
+
+  if instance.Contact != nil {
+    profiles = mergeSocialProfiles(profiles, instance.Contact.SocialProfiles())
+  }
+
+  sortSocialProfiles(profiles)
+
+  return profiles
+}
+
+// SocialProfiles returns the canonical social profiles of the company, from its contact
+func (instance Company) SocialProfiles() []SocialProfile {
+  if instance.Contact == nil {
+    return nil
+  }
+
+  // Bare LinkedIn handles and IDs of companies map to company pages
+  return instance.Contact.socialProfiles(true)
+}
+
+// SocialProfiles returns the canonical social profiles of the contact, taken as a person
+func (instance Contact) SocialProfiles() []SocialProfile {
+  return instance.socialProfiles(false)
+}
+
+
+// socialProfiles returns the canonical social profiles of the contact, of a company or a person
+func (instance Contact) socialProfiles(company bool) []SocialProfile {
+  var profiles []SocialProfile
+
+  for network, value := range map[string]*string{
+    SocialNetworkFacebook: instance.Facebook,
+    SocialNetworkTwitter: instance.Twitter,
+    SocialNetworkLinkedIn: instance.LinkedIn,
+    SocialNetworkYouTube: instance.YouTube,
+    SocialNetworkInstagram: instance.Instagram,
+  } {
+    if value != nil {
+      profiles = appendSocialProfile(profiles, network, *value, company)
+    }
+  }
+
+  if instance.LinkedInID != nil && *instance.LinkedInID != "" {
+    id := strings.TrimSpace(*instance.LinkedInID)
+    merged := false
+
+    for i := range profiles {
+      if profiles[i].Network == SocialNetworkLinkedIn {
+        profiles[i].ID = id
+        merged = true
+      }
+    }
+
+    if merged == false {
+      profile := SocialProfile{Network: SocialNetworkLinkedIn, ID: id, URL: "https://www.linkedin.com/profile/view?id=" + url.QueryEscape(id)}
+
+      if company == true {
+        profile.URL = "https://www.linkedin.com/company/" + url.PathEscape(id)
+      }
+
+      profiles = append(profiles, profile)
+    }
+  }
+
+  sortSocialProfiles(profiles)
+
+  return profiles
+}
+
+
+// canonicalizeSocialURL canonicalizes a social profile URL
+func canonicalizeSocialURL(network string, value string, company bool) (SocialProfile, bool) {
+  if strings.Contains(value, "://") == false {
+    value = "https://" + value
+  }
+
+  parsed, err := url.Parse(value)
+  if err != nil {
+    return SocialProfile{}, false
+  }
+
+  hostNetwork, ok := socialHosts[normalizeSocialHost(parsed.Hostname())]
+  if ok == false || (network != "" && network != hostNetwork) {
+    return SocialProfile{}, false
+  }
+
+  segments := strings.FieldsFunc(parsed.Path, func(character rune) bool {
+    return character == '/'
+  })
+
+  switch hostNetwork {
+    case SocialNetworkFacebook:
+      // Legacy numeric profile URLs (eg. 'profile.php?id=4' or '/pages/Name/123')
+      if len(segments) > 0 && segments[0] == "profile.php" {
+        return newSocialID(hostNetwork, parsed.Query().Get("id"), company)
+      }
+      if len(segments) > 2 && segments[0] == "pages" {
+        return newSocialID(hostNetwork, segments[len(segments) - 1], company)
+      }
+      if len(segments) > 1 && segments[0] == "people" {
+        return newSocialID(hostNetwork, segments[len(segments) - 1], company)
+      }
+
+    case SocialNetworkLinkedIn:
+      if len(segments) > 1 && (segments[0] == "in" || segments[0] == "pub") {
+        return newSocialProfile(hostNetwork, strings.ToLower(segments[1]), "https://www.linkedin.com/in/" + strings.ToLower(segments[1]))
+      }
+      if len(segments) > 1 && (segments[0] == "company" || segments[0] == "school") {
+        return newSocialProfile(hostNetwork, strings.ToLower(segments[1]), "https://www.linkedin.com/" + segments[0] + "/" + strings.ToLower(segments[1]))
+      }
+      if len(segments) > 1 && segments[0] == "profile" && parsed.Query().Get("id") != "" {
+        return newSocialID(hostNetwork, parsed.Query().Get("id"), false)
+      }
+
+      return SocialProfile{}, false
+
+    case SocialNetworkYouTube:
+      if len(segments) > 1 && (segments[0] == "channel" || segments[0] == "user" || segments[0] == "c") {
+        return canonicalizeSocialHandle(hostNetwork, segments[0] + "/" + segments[1], company)
+      }
+  }
+
+  if len(segments) == 0 || containsString(socialReservedPaths[hostNetwork], strings.ToLower(segments[0])) == true {
+    return SocialProfile{}, false
+  }
+
+  return canonicalizeSocialHandle(hostNetwork, segments[0], company)
+}
+
+
+// canonicalizeSocialHandle canonicalizes a social profile handle, of a company or a person
+func canonicalizeSocialHandle(network string, handle string, company bool) (SocialProfile, bool) {
+  handle = strings.TrimPrefix(strings.TrimSpace(handle), "@")
+
+  if handle == "" || strings.ContainsAny(handle, " ?#") == true {
+    return SocialProfile{}, false
+  }
+
+  switch network {
+    case SocialNetworkFacebook:
+      if isDigits(handle) == true {
+        return newSocialID(network, handle, company)
+      }
+
+      return newSocialProfile(network, strings.ToLower(handle), "https://www.facebook.com/" + strings.ToLower(handle))
+
+    case SocialNetworkTwitter:
+      return newSocialProfile(network, strings.ToLower(handle), "https://x.com/" + strings.ToLower(handle))
+
+    case SocialNetworkLinkedIn:
+      if isDigits(handle) == true {
+        return newSocialID(network, handle, company)
+      }
+
+      // LinkedIn pages live under distinct paths for companies and persons
+      if company == true {
+        return newSocialProfile(network, strings.ToLower(handle), "https://www.linkedin.com/company/" + strings.ToLower(handle))
+      }
+
+      return newSocialProfile(network, strings.ToLower(handle), "https://www.linkedin.com/in/" + strings.ToLower(handle))
+
+    case SocialNetworkGitHub:
+      return newSocialProfile(network, strings.ToLower(handle), "https://github.com/" + strings.ToLower(handle))
+
+    case SocialNetworkInstagram:
+      return newSocialProfile(network, strings.ToLower(handle), "https://www.instagram.com/" + strings.ToLower(handle))
+
+    case SocialNetworkYouTube:
+      // Channel IDs are case-sensitive, while user and custom names are not
+      if parts := strings.SplitN(handle, "/", 2); len(parts) == 2 {
+        if parts[0] == "channel" {
+          return SocialProfile{Network: network, ID: parts[1], URL: "https://www.youtube.com/channel/" + parts[1]}, true
+        }
+
+        return newSocialProfile(network, strings.ToLower(parts[1]), "https://www.youtube.com/" + parts[0] + "/" + strings.ToLower(parts[1]))
+      }
+      if strings.HasPrefix(handle, "UC") == true && len(handle) == 24 {
+        return SocialProfile{Network: network, ID: handle, URL: "https://www.youtube.com/channel/" + handle}, true
+      }
+
+      return newSocialProfile(network, strings.ToLower(handle), "https://www.youtube.com/@" + strings.ToLower(handle))
+  }
+
+  return SocialProfile{}, false
+}
+
+
+// newSocialProfile returns a social profile for a handle
+func newSocialProfile(network string, handle string, profileURL string) (SocialProfile, bool) {
+  return SocialProfile{Network: network, Handle: handle, URL: profileURL}, true
+}
+
+
+// newSocialID returns a social profile for a numeric ID, of a company or a person
+func newSocialID(network string, id string, company bool) (SocialProfile, bool) {
+  if id == "" || isDigits(id) == false {
+    return SocialProfile{}, false
+  }
+
+  switch network {
+    case SocialNetworkFacebook:
+      return SocialProfile{Network: network, ID: id, URL: "https://www.facebook.com/profile.php?id=" + id}, true
+
+    case SocialNetworkLinkedIn:
+      if company == true {
+        return SocialProfile{Network: network, ID: id, URL: "https://www.linkedin.com/company/" + id}, true
+      }
+
+      return SocialProfile{Network: network, ID: id, URL: "https://www.linkedin.com/profile/view?id=" + id}, true
+  }
+
+  return SocialProfile{}, false
+}
+
+
+// appendSocialProfile canonicalizes a value and appends it to profiles, if not already present
+func appendSocialProfile(profiles []SocialProfile, network string, value string, company bool) []SocialProfile {
+  profile, ok := canonicalizeSocial(network, value, company)
+  if ok == false {
+    return profiles
+  }
+
+  return mergeSocialProfiles(profiles, []SocialProfile{profile})
+}
+
+
+// mergeSocialProfiles merges profiles into others, completing IDs and handles of profiles already present
+func mergeSocialProfiles(profiles []SocialProfile, others []SocialProfile) []SocialProfile {
+  for _, other := range others {
+    merged := false
+
+    for i := range profiles {
+      if profiles[i].Network != other.Network {
+        continue
+      }
+
+      sameHandle := profiles[i].Handle != "" && profiles[i].Handle == other.Handle
+      sameID := profiles[i].ID != "" && profiles[i].ID == other.ID
+
+      if sameHandle == true || sameID == true || profiles[i].Handle == "" || other.Handle == "" {
+        if profiles[i].Handle == "" && other.Handle != "" {
+          profiles[i].Handle = other.Handle
+          profiles[i].URL = other.URL
+        }
+        if profiles[i].ID == "" {
+          profiles[i].ID = other.ID
+        }
+
+        merged = true
+        break
+      }
+    }
+
+    if merged == false {
+      profiles = append(profiles, other)
+    }
+  }
+
+  return profiles
+}
+
+
+// sortSocialProfiles sorts profiles by network then URL, for a stable order
+func sortSocialProfiles(profiles []SocialProfile) {
+  sort.Slice(profiles, func(i, j int) bool {
+    if profiles[i].Network != profiles[j].Network {
+      return profiles[i].Network < profiles[j].Network
+    }
+
+    return profiles[i].URL < profiles[j].URL
+  })
+}
+
+
+// normalizeSocialHost strips mobile, language and 'www' subdomains from a host
+func normalizeSocialHost(host string) string {
+  host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+  for domain := range socialHosts {
+    if host == domain || strings.HasSuffix(host, "." + domain) == true {
+      return domain
+    }
+  }
+
+  return host
+}
+
+
+// looksLikeURL returns whether a value looks like an URL rather than a handle
+func looksLikeURL(value string) bool {
+  if strings.Contains(value, "://") == true {
+    return true
+  }
+
+  host := strings.ToLower(strings.SplitN(value, "/", 2)[0])
+
+  _, ok := socialHosts[normalizeSocialHost(host)]
+
+  return ok
+}
@@ -8,9 +8,12 @@ package enrich
 
 import (
   "bytes"
+  "encoding/json"
   "fmt"
   "io"
   "reflect"
+  "sort"
+  "time"
 )
 
 
@@ -39,6 +42,11 @@ func stringifyValue(w io.Writer, val reflect.Value) {
       fmt.Fprintf(w, `"%s"`, v)
 
     case reflect.Slice:
+      if v.Type() == reflect.TypeOf(json.RawMessage{}) {
+        w.Write(v.Bytes())
+        return
+      }
+
       w.Write([]byte{'['})
       for i := 0; i < v.Len(); i++ {
         if i > 0 {
@@ -51,7 +59,32 @@ func stringifyValue(w io.Writer, val reflect.Value) {
       w.Write([]byte{']'})
       return
 
+    case reflect.Map:
+      keys := v.MapKeys()
+
+      sort.Slice(keys, func(i, j int) bool {
+        return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+      })
+
+      w.Write([]byte("map["))
+      for i, key := range keys {
+        if i > 0 {
+          w.Write([]byte{' '})
+        }
+
+        fmt.Fprint(w, key.Interface())
+        w.Write([]byte{':'})
+        stringifyValue(w, v.MapIndex(key))
+      }
+
+      w.Write([]byte{']'})
+
     case reflect.Struct:
+      if v.Type() == reflect.TypeOf(time.Time{}) && v.CanInterface() {
+        fmt.Fprintf(w, `"%s"`, v.Interface().(time.Time).Format(time.RFC3339))
+        return
+      }
+
       if v.Type().Name() != "" {
         w.Write([]byte(v.Type().String()))
       }
@@ -68,6 +101,9 @@ func stringifyValue(w io.Writer, val reflect.Value) {
         if fv.Kind() == reflect.Slice && fv.IsNil() {
           continue
         }
+        if fv.Kind() == reflect.Map && fv.IsNil() {
+          continue
+        }
 
         if sep {
           w.Write([]byte(", "))
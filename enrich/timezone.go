@@ -0,0 +1,294 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "errors"
+  "fmt"
+  "math"
+  "strconv"
+  "strings"
+  "time"
+)
+
+
+const (
+  defaultBusinessHoursStart = 9 * time.Hour
+  defaultBusinessHoursEnd = 18 * time.Hour
+  businessHoursSearchDays = 8
+)
+
+// ErrUnknownTimezone is returned when no timezone can be resolved for a record
+var ErrUnknownTimezone = errors.New("unknown timezone")
+
+
+// BusinessHours maps daily business hours, as offsets from midnight (local time)
+type BusinessHours struct {
+  Start     time.Duration
+  End       time.Duration
+  Weekdays  []time.Weekday
+}
+
+
+// String returns the string representation of BusinessHours
+func (instance BusinessHours) String() string {
+  return Stringify(instance)
+}
+
+
+// DefaultBusinessHours returns business hours from 9:00 to 18:00, Monday to Friday
+func DefaultBusinessHours() BusinessHours {
+  return BusinessHours{
+    Start: defaultBusinessHoursStart,
+    End: defaultBusinessHoursEnd,
+    Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+  }
+}
+
+
+// Contains returns whether a time falls within business hours (in the time location)
+func (hours BusinessHours) Contains(at time.Time) bool {
+  if hours.isWorkday(at.Weekday()) == false {
+    return false
+  }
+
+  hour, minute, second := at.Clock()
+  offset := time.Duration(hour) * time.Hour + time.Duration(minute) * time.Minute + time.Duration(second) * time.Second
+
+  return offset >= hours.Start && offset < hours.End
+}
+
+
+// Next returns the first time within business hours, starting from a given time (in the time location)
+func (hours BusinessHours) Next(from time.Time) (time.Time, bool) {
+  if hours.Contains(from) == true {
+    return from, true
+  }
+
+  day := startOfDay(from)
+
+  for i := 0; i < businessHoursSearchDays; i++ {
+    if start := atWallClock(day, hours.Start); hours.isWorkday(day.Weekday()) == true && start.After(from) == true {
+      return start, true
+    }
+
+    day = startOfDay(day.AddDate(0, 0, 1))
+  }
+
+  return time.Time{}, false
+}
+
+
+// isWorkday returns whether a weekday is a workday
+func (hours BusinessHours) isWorkday(weekday time.Weekday) bool {
+  for _, current := range hours.Weekdays {
+    if current == weekday {
+      return true
+    }
+  }
+
+  return false
+}
+
+
+// LoadTimezone resolves a timezone name (eg. 'Europe/Paris') or UTC offset (eg. '+01:00', 'UTC-5')
+func LoadTimezone(name string) (*time.Location, error) {
+  name = strings.TrimSpace(name)
+
+  if name == "" {
+    return nil, ErrUnknownTimezone
+  }
+
+  if location, err := time.LoadLocation(name); err == nil {
+    return location, nil
+  }
+
+  if location, ok := parseTimezoneOffset(name); ok == true {
+    return location, nil
+  }
+
+  return nil, fmt.Errorf("%v: %s", ErrUnknownTimezone, name)
+}
+
+
+// Location returns the timezone of the person, if known
+func (instance Person) Location() (*time.Location, error) {
+  return loadRecordTimezone(instance.Timezone)
+}
+
+// Location returns the timezone of the company, if known
+func (instance Company) Location() (*time.Location, error) {
+  return loadRecordTimezone(instance.Timezone)
+}
+
+
+// ApproximateLocation returns the timezone of the person, or else a fixed timezone approximated from its coordinates (in which case approximate is true)
+//
+// Approximated timezones follow longitude only, thus ignore daylight saving time and actual timezone boundaries (they may be off by a few hours).
+func (instance Person) ApproximateLocation() (location *time.Location, approximate bool, err error) {
+  if location, err := instance.Location(); err == nil {
+    return location, false, nil
+  }
+
+  if instance.Address != nil {
+    if location, ok := locationFromCoordinates(instance.Address.Coordinates); ok == true {
+      return location, true, nil
+    }
+  }
+  if instance.Geolocation != nil {
+    if location, ok := locationFromCoordinates(instance.Geolocation.Coordinates); ok == true {
+      return location, true, nil
+    }
+  }
+
+  return nil, false, ErrUnknownTimezone
+}
+
+// ApproximateLocation returns the timezone of the company, or else a fixed timezone approximated from its coordinates (in which case approximate is true)
+func (instance Company) ApproximateLocation() (location *time.Location, approximate bool, err error) {
+  if location, err := instance.Location(); err == nil {
+    return location, false, nil
+  }
+
+  if instance.Address != nil {
+    if location, ok := locationFromCoordinates(instance.Address.Coordinates); ok == true {
+      return location, true, nil
+    }
+  }
+
+  return nil, false, ErrUnknownTimezone
+}
+
+// ApproximateLocation returns a fixed timezone approximated from the network coordinates (networks have no known timezone, thus approximate is always true)
+func (instance Network) ApproximateLocation() (location *time.Location, approximate bool, err error) {
+  if instance.Geolocation != nil {
+    if location, ok := locationFromCoordinates(instance.Geolocation.Coordinates); ok == true {
+      return location, true, nil
+    }
+  }
+
+  return nil, false, ErrUnknownTimezone
+}
+
+
+// LocalTime returns the current local time of the person (see Location)
+func (instance Person) LocalTime() (time.Time, error) {
+  return localTimeNow(instance.Location())
+}
+
+// LocalTime returns the current local time of the company (see Location)
+func (instance Company) LocalTime() (time.Time, error) {
+  return localTimeNow(instance.Location())
+}
+
+
+// InBusinessHours returns whether it currently is business hours for the person (see Location)
+func (instance Person) InBusinessHours(hours BusinessHours) (bool, error) {
+  now, err := instance.LocalTime()
+  if err != nil {
+    return false, err
+  }
+
+  return hours.Contains(now), nil
+}
+
+// InBusinessHours returns whether it currently is business hours for the company (see Location)
+func (instance Company) InBusinessHours(hours BusinessHours) (bool, error) {
+  now, err := instance.LocalTime()
+  if err != nil {
+    return false, err
+  }
+
+  return hours.Contains(now), nil
+}
+
+
+// loadRecordTimezone loads the timezone of a record, if any
+func loadRecordTimezone(name *string) (*time.Location, error) {
+  if name == nil {
+    return nil, ErrUnknownTimezone
+  }
+
+  return LoadTimezone(*name)
+}
+
+
+// localTimeNow returns the current time in a location
+func localTimeNow(location *time.Location, err error) (time.Time, error) {
+  if err != nil {
+    return time.Time{}, err
+  }
+
+  return time.Now().In(location), nil
+}
+
+
+// startOfDay returns midnight of the day of a time (in the time location)
+func startOfDay(at time.Time) time.Time {
+  return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+}
+
+
+// atWallClock returns the time at a wall clock offset from midnight, on the day of a time
+func atWallClock(day time.Time, offset time.Duration) time.Time {
+  return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, int(offset / time.Second), 0, day.Location())
+}
+
+
+// parseTimezoneOffset parses a UTC offset (eg. '+01:00', '-0530', 'UTC+2', 'GMT-05:00')
+func parseTimezoneOffset(name string) (*time.Location, bool) {
+  offset := strings.ToUpper(name)
+  offset = strings.TrimPrefix(strings.TrimPrefix(offset, "UTC"), "GMT")
+
+  if len(offset) < 2 || (offset[0] != '+' && offset[0] != '-') {
+    return nil, false
+  }
+
+  sign := 1
+
+  if offset[0] == '-' {
+    sign = -1
+  }
+
+  // Accepted forms are 'h', 'hh', 'hhmm' and 'hh:mm'
+  digits := offset[1:]
+
+  if len(digits) == 5 && digits[2] == ':' {
+    digits = digits[:2] + digits[3:]
+  }
+  if digits == "" || isDigits(digits) == false || len(digits) == 3 || len(digits) > 4 {
+    return nil, false
+  }
+
+  hours, _ := strconv.Atoi(digits)
+  minutes := 0
+
+  if len(digits) == 4 {
+    hours, _ = strconv.Atoi(digits[:2])
+    minutes, _ = strconv.Atoi(digits[2:])
+  }
+
+  if hours > 14 || minutes > 59 {
+    return nil, false
+  }
+
+  seconds := sign * (hours * 3600 + minutes * 60)
+
+  return time.FixedZone(fmt.Sprintf("UTC%s", offset), seconds), true
+}
+
+
+// locationFromCoordinates approximates a fixed timezone from a longitude (15 degrees per hour)
+func locationFromCoordinates(coordinates *Coordinates) (*time.Location, bool) {
+  if coordinates == nil || coordinates.Longitude == nil {
+    return nil, false
+  }
+
+  hours := int(math.Round(float64(*coordinates.Longitude) / 15))
+
+  return time.FixedZone(fmt.Sprintf("UTC%+d", hours), hours * 3600), true
+}
@@ -0,0 +1,806 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "fmt"
+  "strconv"
+  "strings"
+  "unicode/utf8"
+)
+
+
+const (
+  vcardVersion = "4.0"
+  vcardLineLength = 75
+  vcardLineBreak = "\r\n"
+)
+
+
+// VCardError maps a vCard that could not be parsed
+type VCardError struct {
+  Line    int
+  Reason  string
+}
+
+// vcardProperty maps a vCard content line
+type vcardProperty struct {
+  name    string
+  params  map[string][]string
+  value   string
+  line    int
+}
+
+// vcardWriter builds a vCard, folding lines as they are written
+type vcardWriter struct {
+  builder  strings.Builder
+}
+
+
+// Error prints a vCard error
+func (err *VCardError) Error() string {
+  return fmt.Sprintf("invalid_vcard vCard could not be parsed at line %d: %s", err.Line, err.Reason)
+}
+
+
+// VCard returns the person as a vCard 4.0
+//
+// The current employment (ie. the first one) is exported as ORG and TITLE.
+func (instance Person) VCard() string {
+  writer := newVCardWriter()
+
+  if instance.ID != nil {
+    writer.property("UID", []string{"VALUE=text"}, escapeVCardText(*instance.ID))
+  }
+
+  writer.name(instance.Name, instance.Contact)
+
+  if instance.Gender != nil {
+    switch ParseGender(string(*instance.Gender)) {
+      case GenderMale:
+        writer.property("GENDER", nil, "M")
+
+      case GenderFemale:
+        writer.property("GENDER", nil, "F")
+    }
+  }
+
+  if instance.Avatar != nil && *instance.Avatar != "" {
+    writer.property("PHOTO", nil, *instance.Avatar)
+  }
+  if instance.Description != nil && *instance.Description != "" {
+    writer.property("NOTE", nil, escapeVCardText(*instance.Description))
+  }
+
+  var region string
+
+  if instance.Address != nil && instance.Address.Country != nil {
+    region = *instance.Address.Country
+  } else if instance.Geolocation != nil && instance.Geolocation.Country != nil {
+    region = *instance.Geolocation.Country
+  }
+
+  writer.contact(instance.Contact, region, "")
+  writer.address(instance.Address)
+
+  if instance.Timezone != nil && *instance.Timezone != "" {
+    writer.property("TZ", nil, escapeVCardText(*instance.Timezone))
+  }
+
+  if instance.Locales != nil {
+    for i, value := range *instance.Locales {
+      if locale, ok := ParseLocale(value); ok == true {
+        writer.property("LANG", []string{"PREF=" + strconv.Itoa(i + 1)}, locale.String())
+      }
+    }
+  }
+
+  writer.socialProfiles(instance.SocialProfiles())
+
+  if instance.Employments != nil && len(*instance.Employments) > 0 {
+    employment := (*instance.Employments)[0]
+
+    writer.employment(employment.Name, employment.Title, employment.Role)
+  }
+
+  return writer.end()
+}
+
+
+// VCard returns the company employee as a vCard 4.0, using the company (if any) as organization
+func (instance CompanyEmployeesPerson) VCard(company *Company) string {
+  writer := newVCardWriter()
+
+  if instance.ID != nil {
+    writer.property("UID", []string{"VALUE=text"}, escapeVCardText(*instance.ID))
+  }
+
+  writer.name(instance.Name, instance.Contact)
+
+  var region string
+  var organization *string
+
+  if company != nil {
+    organization = company.Name
+
+    if company.Address != nil && company.Address.Country != nil {
+      region = *company.Address.Country
+    }
+  }
+
+  writer.contact(instance.Contact, region, "work")
+
+  if instance.Contact != nil {
+    writer.socialProfiles(instance.Contact.SocialProfiles())
+  }
+
+  if instance.Employment != nil {
+    writer.employment(organization, instance.Employment.Title, instance.Employment.Role)
+  } else {
+    writer.employment(organization, nil, nil)
+  }
+
+  return writer.end()
+}
+
+
+// ParseVCard parses a single vCard into a person
+func ParseVCard(data string) (*Person, error) {
+  persons, err := ParseVCards(data)
+  if err != nil {
+    return nil, err
+  }
+
+  if len(persons) != 1 {
+    return nil, &VCardError{Line: 1, Reason: fmt.Sprintf("expected 1 vCard, got %d", len(persons))}
+  }
+
+  return &persons[0], nil
+}
+
+
+// ParseVCards parses a stream of vCards (eg. an address book export) into persons
+//
+// Social profiles are restored into the person social networks, and ORG, TITLE and ROLE into its first employment.
+func ParseVCards(data string) ([]Person, error) {
+  var persons []Person
+  var card []vcardProperty
+
+  inside := false
+  lines := unfoldVCard(data)
+
+  for _, line := range lines {
+    if strings.TrimSpace(line.value) == "" {
+      continue
+    }
+
+    property, err := parseVCardLine(line.value, line.line)
+    if err != nil {
+      return nil, err
+    }
+
+    switch {
+      case property.name == "BEGIN" && strings.EqualFold(property.value, "VCARD"):
+        if inside == true {
+          return nil, &VCardError{Line: property.line, Reason: "nested BEGIN:VCARD"}
+        }
+
+        inside, card = true, nil
+
+      case property.name == "END" && strings.EqualFold(property.value, "VCARD"):
+        if inside == false {
+          return nil, &VCardError{Line: property.line, Reason: "END:VCARD without BEGIN:VCARD"}
+        }
+
+        persons = append(persons, personFromVCard(card))
+        inside = false
+
+      case inside == false:
+        return nil, &VCardError{Line: property.line, Reason: fmt.Sprintf("property %s outside of a vCard", property.name)}
+
+      default:
+        card = append(card, property)
+    }
+  }
+
+  if inside == true {
+    return nil, &VCardError{Line: len(lines), Reason: "missing END:VCARD"}
+  }
+
+  return persons, nil
+}
+
+
+// newVCardWriter returns a vCard writer, with the card opened
+func newVCardWriter() *vcardWriter {
+  writer := &vcardWriter{}
+
+  writer.property("BEGIN", nil, "VCARD")
+  writer.property("VERSION", nil, vcardVersion)
+  writer.property("KIND", nil, "individual")
+
+  return writer
+}
+
+
+// property writes a content line, folded to 75 octets (values must already be escaped)
+func (writer *vcardWriter) property(name string, params []string, value string) {
+  line := name
+
+  for _, param := range params {
+    line += ";" + param
+  }
+
+  // Invalid UTF-8 (which API data may hold) is replaced, as vCards are UTF-8 only
+  line += ":" + strings.ToValidUTF8(value, "\uFFFD")
+
+  for len(line) > vcardLineLength {
+    // Never split a multi-byte character
+    cut := vcardLineLength
+
+    for cut > 1 && utf8.RuneStart(line[cut]) == false {
+      cut--
+    }
+
+    // Lines must make progress past the continuation space, thus fall back on a hard cut
+    if cut <= 1 {
+      cut = vcardLineLength
+    }
+
+    writer.builder.WriteString(line[:cut] + vcardLineBreak)
+
+    // Continuation lines start with a space, which counts in the line length
+    line = " " + line[cut:]
+  }
+
+  writer.builder.WriteString(line + vcardLineBreak)
+}
+
+
+// name writes the FN and N properties (FN is mandatory, so it falls back on the first email)
+func (writer *vcardWriter) name(name *Name, contact *Contact) {
+  var full, first, last string
+
+  if name != nil {
+    if name.First != nil {
+      first = *name.First
+    }
+    if name.Last != nil {
+      last = *name.Last
+    }
+
+    if name.Full != nil {
+      full = *name.Full
+    } else {
+      full = strings.TrimSpace(first + " " + last)
+    }
+  }
+
+  if full == "" && contact != nil && contact.Emails != nil && len(*contact.Emails) > 0 {
+    full = (*contact.Emails)[0]
+  }
+
+  writer.property("FN", nil, escapeVCardText(full))
+
+  if first != "" || last != "" {
+    writer.property("N", nil, joinVCardComponents(last, first, "", "", ""))
+  }
+}
+
+
+// contact writes the emails, phones and website of a contact
+func (writer *vcardWriter) contact(contact *Contact, region string, kind string) {
+  if contact == nil {
+    return
+  }
+
+  var params []string
+
+  if kind != "" {
+    params = append(params, "TYPE=" + kind)
+  }
+
+  if contact.Emails != nil {
+    for i, email := range *contact.Emails {
+      writer.property("EMAIL", withVCardPref(params, i), escapeVCardText(email))
+    }
+  }
+
+  for i, number := range normalizePhones(contact, region) {
+    if number.Valid == true {
+      value := "tel:" + number.E164
+
+      if number.Extension != "" {
+        value += ";ext=" + number.Extension
+      }
+
+      writer.property("TEL", withVCardPref(append([]string{"VALUE=uri"}, params...), i), value)
+    } else {
+      writer.property("TEL", withVCardPref(append([]string{"VALUE=text"}, params...), i), escapeVCardText(number.Raw))
+    }
+  }
+
+  if contact.Website != nil && *contact.Website != "" {
+    writer.property("URL", params, *contact.Website)
+  }
+}
+
+
+// address writes an address, with its coordinates
+func (writer *vcardWriter) address(address *Address) {
+  if address == nil {
+    return
+  }
+
+  var params []string
+
+  if address.Coordinates != nil && address.Coordinates.Valid() == true {
+    params = append(params, fmt.Sprintf("GEO=\"geo:%s,%s\"", formatVCardFloat(*address.Coordinates.Latitude), formatVCardFloat(*address.Coordinates.Longitude)))
+  }
+
+  writer.property("ADR", params, joinVCardComponents("", "", stringValue(address.Street), stringValue(address.City), stringValue(address.Region), stringValue(address.Postcode), stringValue(address.Country)))
+}
+
+
+// socialProfiles writes social profiles (as defined in RFC 9554)
+func (writer *vcardWriter) socialProfiles(profiles []SocialProfile) {
+  for _, profile := range profiles {
+    writer.property("SOCIALPROFILE", []string{"SERVICE-TYPE=" + profile.Network}, profile.URL)
+  }
+}
+
+
+// employment writes an organization, title and role
+func (writer *vcardWriter) employment(organization *string, title *string, role *Role) {
+  if organization != nil && *organization != "" {
+    writer.property("ORG", nil, escapeVCardText(*organization))
+  }
+  if title != nil && *title != "" {
+    writer.property("TITLE", nil, escapeVCardText(*title))
+  }
+  if role != nil && *role != "" {
+    writer.property("ROLE", nil, escapeVCardText(string(*role)))
+  }
+}
+
+
+// end closes the card and returns it
+func (writer *vcardWriter) end() string {
+  writer.property("END", nil, "VCARD")
+
+  return writer.builder.String()
+}
+
+
+// personFromVCard maps the properties of a vCard to a person
+func personFromVCard(card []vcardProperty) Person {
+  person := Person{}
+  contact := Contact{}
+
+  var emails, phones, locales []string
+  var employment PersonEmployment
+
+  for _, property := range card {
+    switch property.name {
+      case "UID":
+        person.ID = stringPointer(unescapeVCardText(property.value))
+
+      case "FN":
+        if full := unescapeVCardText(property.value); full != "" {
+          person.Name = withName(person.Name)
+          person.Name.Full = &full
+        }
+
+      case "N":
+        components := splitVCardComponents(property.value)
+
+        person.Name = withName(person.Name)
+        person.Name.Last = nonEmptyPointer(vcardComponent(components, 0))
+        person.Name.First = nonEmptyPointer(vcardComponent(components, 1))
+
+      case "GENDER":
+        switch strings.ToUpper(vcardComponent(splitVCardComponents(property.value), 0)) {
+          case "M":
+            gender := GenderMale
+            person.Gender = &gender
+
+          case "F":
+            gender := GenderFemale
+            person.Gender = &gender
+        }
+
+      case "PHOTO":
+        // Inline photos (vCard 3.0) are not URLs, thus cannot be mapped to an avatar
+        if _, inline := property.params["ENCODING"]; inline == false {
+          person.Avatar = nonEmptyPointer(property.value)
+        }
+
+      case "NOTE":
+        person.Description = nonEmptyPointer(unescapeVCardText(property.value))
+
+      case "TZ":
+        person.Timezone = nonEmptyPointer(unescapeVCardText(property.value))
+
+      case "EMAIL":
+        emails = append(emails, unescapeVCardText(property.value))
+
+      case "TEL":
+        phone := property.value
+
+        if strings.HasPrefix(strings.ToLower(phone), "tel:") == true {
+          phone = strings.Replace(phone[4:], ";ext=", " ext. ", 1)
+        } else {
+          phone = unescapeVCardText(phone)
+        }
+
+        phones = append(phones, phone)
+
+      case "URL":
+        if profile, ok := CanonicalizeSocial("", property.value); ok == true {
+          setPersonSocial(&person, profile)
+        } else if contact.Website == nil {
+          contact.Website = nonEmptyPointer(property.value)
+        }
+
+      case "SOCIALPROFILE", "X-SOCIALPROFILE":
+        network := vcardParam(property, "SERVICE-TYPE")
+
+        if network == "" {
+          network = vcardParam(property, "TYPE")
+        }
+
+        if profile, ok := CanonicalizeSocial(network, property.value); ok == true {
+          setPersonSocial(&person, profile)
+        }
+
+      case "ADR":
+        person.Address = addressFromVCard(property)
+
+      case "LANG":
+        locales = append(locales, unescapeVCardText(property.value))
+
+      case "ORG":
+        employment.Name = nonEmptyPointer(vcardComponent(splitVCardComponents(property.value), 0))
+
+      case "TITLE":
+        employment.Title = nonEmptyPointer(unescapeVCardText(property.value))
+
+      case "ROLE":
+        if value := unescapeVCardText(property.value); value != "" {
+          role := ParseRole(value)
+          employment.Role = &role
+        }
+    }
+  }
+
+  if len(emails) > 0 {
+    contact.Emails = &emails
+  }
+  if len(phones) > 0 {
+    contact.Phones = &phones
+  }
+  if contact.Emails != nil || contact.Phones != nil || contact.Website != nil {
+    person.Contact = &contact
+  }
+
+  if len(locales) > 0 {
+    person.Locales = &locales
+  }
+
+  if employment.Name != nil || employment.Title != nil || employment.Role != nil {
+    person.Employments = &[]PersonEmployment{employment}
+  }
+
+  return person
+}
+
+
+// addressFromVCard maps an ADR property to an address
+func addressFromVCard(property vcardProperty) *Address {
+  components := splitVCardComponents(property.value)
+
+  address := &Address{
+    Street: nonEmptyPointer(vcardComponent(components, 2)),
+    City: nonEmptyPointer(vcardComponent(components, 3)),
+    Region: nonEmptyPointer(vcardComponent(components, 4)),
+    Postcode: nonEmptyPointer(vcardComponent(components, 5)),
+    Country: nonEmptyPointer(vcardComponent(components, 6)),
+  }
+
+  if geo := vcardParam(property, "GEO"); strings.HasPrefix(strings.ToLower(geo), "geo:") == true {
+    parts := strings.SplitN(strings.SplitN(geo[4:], ";", 2)[0], ",", 3)
+
+    if len(parts) >= 2 {
+      latitude, latitudeErr := strconv.ParseFloat(parts[0], 32)
+      longitude, longitudeErr := strconv.ParseFloat(parts[1], 32)
+
+      if latitudeErr == nil && longitudeErr == nil {
+        latitudeValue, longitudeValue := float32(latitude), float32(longitude)
+
+        address.Coordinates = &Coordinates{Latitude: &latitudeValue, Longitude: &longitudeValue}
+      }
+    }
+  }
+
+  return address
+}
+
+
+// setPersonSocial stores a social profile in the person social networks
+func setPersonSocial(person *Person, profile SocialProfile) {
+  if person.Social == nil {
+    person.Social = &PersonSocial{}
+  }
+
+  social := &PersonSocialNetwork{URL: nonEmptyPointer(profile.URL), Handle: nonEmptyPointer(profile.Handle)}
+
+  switch profile.Network {
+    case SocialNetworkFacebook:
+      person.Social.Facebook = social
+
+    case SocialNetworkTwitter:
+      person.Social.Twitter = social
+
+    case SocialNetworkLinkedIn:
+      person.Social.LinkedIn = social
+
+    case SocialNetworkGitHub:
+      person.Social.GitHub = social
+
+    case SocialNetworkYouTube:
+      person.Social.YouTube = social
+
+    case SocialNetworkInstagram:
+      person.Social.Instagram = social
+  }
+}
+
+
+// vcardLine maps a logical (unfolded) line, with the number of its first physical line
+type vcardLine struct {
+  value  string
+  line   int
+}
+
+// unfoldVCard splits data into logical lines, joining folded lines
+func unfoldVCard(data string) []vcardLine {
+  var lines []vcardLine
+
+  physical := strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n")
+
+  for i, line := range physical {
+    if len(lines) > 0 && (strings.HasPrefix(line, " ") == true || strings.HasPrefix(line, "\t") == true) {
+      lines[len(lines) - 1].value += line[1:]
+    } else {
+      lines = append(lines, vcardLine{value: line, line: i + 1})
+    }
+  }
+
+  return lines
+}
+
+
+// parseVCardLine parses a content line (eg. 'item1.TEL;TYPE=work,voice:+33 1 23 45 67 89')
+func parseVCardLine(line string, number int) (vcardProperty, error) {
+  property := vcardProperty{params: make(map[string][]string), line: number}
+
+  quoted := false
+  separator := -1
+
+  for i := 0; i < len(line) && separator < 0; i++ {
+    switch line[i] {
+      case '"':
+        quoted = !quoted
+
+      case ':':
+        if quoted == false {
+          separator = i
+        }
+    }
+  }
+
+  if separator < 0 {
+    return property, &VCardError{Line: number, Reason: "missing ':' separator"}
+  }
+
+  property.value = line[separator + 1:]
+
+  parts := splitVCardUnquoted(line[:separator], ';')
+
+  // Strip the group, if any (eg. 'item1.EMAIL')
+  name := parts[0]
+
+  if index := strings.LastIndex(name, "."); index >= 0 {
+    name = name[index + 1:]
+  }
+
+  property.name = strings.ToUpper(strings.TrimSpace(name))
+
+  if property.name == "" {
+    return property, &VCardError{Line: number, Reason: "missing property name"}
+  }
+
+  for _, param := range parts[1:] {
+    key, value := "TYPE", param
+
+    // Parameters without a name are types (vCard 2.1, eg. 'TEL;WORK')
+    if index := strings.Index(param, "="); index >= 0 {
+      key, value = param[:index], param[index + 1:]
+    }
+
+    key = strings.ToUpper(strings.TrimSpace(key))
+
+    for _, item := range splitVCardUnquoted(value, ',') {
+      property.params[key] = append(property.params[key], decodeVCardParam(item))
+    }
+  }
+
+  return property, nil
+}
+
+
+// splitVCardUnquoted splits a value on a separator, ignoring separators in quotes
+func splitVCardUnquoted(value string, separator byte) []string {
+  var parts []string
+
+  quoted := false
+  start := 0
+
+  for i := 0; i < len(value); i++ {
+    if value[i] == '"' {
+      quoted = !quoted
+    } else if value[i] == separator && quoted == false {
+      parts = append(parts, value[start:i])
+      start = i + 1
+    }
+  }
+
+  return append(parts, value[start:])
+}
+
+
+// vcardParam returns the first value of a property parameter
+func vcardParam(property vcardProperty, name string) string {
+  if values := property.params[name]; len(values) > 0 {
+    return values[0]
+  }
+
+  return ""
+}
+
+
+// escapeVCardText escapes a text value (RFC 6350 section 3.4)
+func escapeVCardText(value string) string {
+  replacer := strings.NewReplacer("\\", "\\\\", ",", "\\,", ";", "\\;", "\r\n", "\\n", "\n", "\\n", "\r", "\\n")
+
+  return replacer.Replace(value)
+}
+
+
+// unescapeVCardText unescapes a text value
+func unescapeVCardText(value string) string {
+  var builder strings.Builder
+
+  for i := 0; i < len(value); i++ {
+    if value[i] == '\\' && i + 1 < len(value) {
+      i++
+
+      if value[i] == 'n' || value[i] == 'N' {
+        builder.WriteByte('\n')
+      } else {
+        builder.WriteByte(value[i])
+      }
+    } else {
+      builder.WriteByte(value[i])
+    }
+  }
+
+  return builder.String()
+}
+
+
+// joinVCardComponents joins the components of a structured value, escaping them
+func joinVCardComponents(components ...string) string {
+  for i := range components {
+    components[i] = escapeVCardText(components[i])
+  }
+
+  return strings.Join(components, ";")
+}
+
+
+// splitVCardComponents splits a structured value on unescaped semicolons, unescaping components
+func splitVCardComponents(value string) []string {
+  var components []string
+
+  start := 0
+
+  for i := 0; i < len(value); i++ {
+    if value[i] == '\\' {
+      i++
+    } else if value[i] == ';' {
+      components = append(components, unescapeVCardText(value[start:i]))
+      start = i + 1
+    }
+  }
+
+  return append(components, unescapeVCardText(value[start:]))
+}
+
+
+// vcardComponent returns a component of a structured value, if any
+func vcardComponent(components []string, index int) string {
+  if index < len(components) {
+    return strings.TrimSpace(components[index])
+  }
+
+  return ""
+}
+
+
+// decodeVCardParam unquotes a parameter value, decoding RFC 6868 escapes (eg. '^n' for new lines)
+func decodeVCardParam(value string) string {
+  value = strings.TrimSpace(value)
+
+  if len(value) >= 2 && value[0] == '"' && value[len(value) - 1] == '"' {
+    value = value[1:len(value) - 1]
+  }
+
+  return strings.NewReplacer("^n", "\n", "^'", "\"", "^^", "^").Replace(value)
+}
+
+
+// withVCardPref adds a preference parameter to the first value of a list
+func withVCardPref(params []string, index int) []string {
+  if index == 0 {
+    return append(append([]string{}, params...), "PREF=1")
+  }
+
+  return params
+}
+
+
+// formatVCardFloat formats a coordinate without floating-point noise
+func formatVCardFloat(value float32) string {
+  return strconv.FormatFloat(float64(value), 'f', -1, 32)
+}
+
+
+// withName returns a name, allocating it if needed
+func withName(name *Name) *Name {
+  if name == nil {
+    return &Name{}
+  }
+
+  return name
+}
+
+
+// stringValue returns the value of a string pointer, or an empty string
+func stringValue(value *string) string {
+  if value == nil {
+    return ""
+  }
+
+  return *value
+}
+
+
+// stringPointer returns a pointer to a string
+func stringPointer(value string) *string {
+  return &value
+}
+
+
+// nonEmptyPointer returns a pointer to a string, or nil if empty
+func nonEmptyPointer(value string) *string {
+  if value == "" {
+    return nil
+  }
+
+  return &value
+}
@@ -0,0 +1,81 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "reflect"
+  "strings"
+  "testing"
+  "unicode/utf8"
+)
+
+
+func TestVCardRoundTrip(t *testing.T) {
+  tests := []struct {
+    label        string
+    full         string
+    description  string
+    emails       []string
+    title        string
+    want         string
+  }{
+    {"simple", "Valerian Saliou", "Co-founder of Crisp", []string{"valerian@crisp.chat"}, "CEO", ""},
+    {"folded", "Valerian Saliou", strings.Repeat("Crisp is a customer messaging platform. ", 10), nil, "", ""},
+    {"escaped", "Saliou, Valerian", "First line\nSecond line; with, separators\\ and a backslash", nil, "CTO; Founder", ""},
+    {"multi-byte", "José Ñúñez", strings.Repeat("日本語のテキスト、", 20) + "🚀", nil, "Directeur général", ""},
+    {"folded at a multi-byte boundary", "Zoë", strings.Repeat("a", 69) + strings.Repeat("é", 40), nil, "", ""},
+    {"invalid utf-8", "Valerian", "NOTE" + strings.Repeat("\x80", 100) + strings.Repeat("z", 100), nil, "", "NOTE�" + strings.Repeat("z", 100)},
+  }
+
+  for _, test := range tests {
+    person := Person{Name: &Name{Full: stringPointer(test.full)}, Description: stringPointer(test.description)}
+
+    if test.emails != nil {
+      emails := test.emails
+      person.Contact = &Contact{Emails: &emails}
+    }
+    if test.title != "" {
+      person.Employments = &[]PersonEmployment{{Title: stringPointer(test.title)}}
+    }
+
+    card := person.VCard()
+
+    for _, line := range strings.Split(strings.TrimSuffix(card, vcardLineBreak), vcardLineBreak) {
+      if len(line) > vcardLineLength {
+        t.Errorf("%s: line is %d octets long: %q", test.label, len(line), line)
+      }
+      if utf8.ValidString(line) == false {
+        t.Errorf("%s: line splits a multi-byte character: %q", test.label, line)
+      }
+    }
+
+    parsed, err := ParseVCard(card)
+    if err != nil {
+      t.Fatalf("%s: ParseVCard() returned error: %v", test.label, err)
+    }
+
+    want := test.want
+
+    if want == "" {
+      want = test.description
+    }
+
+    if parsed.Name == nil || stringValue(parsed.Name.Full) != test.full {
+      t.Errorf("%s: full name = %v, want %q", test.label, parsed.Name, test.full)
+    }
+    if stringValue(parsed.Description) != want {
+      t.Errorf("%s: description = %q, want %q", test.label, stringValue(parsed.Description), want)
+    }
+
+    if test.emails != nil && (parsed.Contact == nil || parsed.Contact.Emails == nil || reflect.DeepEqual(*parsed.Contact.Emails, test.emails) == false) {
+      t.Errorf("%s: emails = %v, want %q", test.label, parsed.Contact, test.emails)
+    }
+    if test.title != "" && (parsed.Employments == nil || stringValue((*parsed.Employments)[0].Title) != test.title) {
+      t.Errorf("%s: employments = %v, want title %q", test.label, parsed.Employments, test.title)
+    }
+  }
+}
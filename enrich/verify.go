@@ -7,6 +7,7 @@ package enrich
 
 
 import (
+  "encoding/json"
   "fmt"
   "net/url"
 )
@@ -18,24 +19,26 @@ type VerifyService service
 
 // ValidateEmailData mapping
 type ValidateEmailData struct {
-  Valid     *bool                  `json:"valid,omitempty"`
-  Accuracy  *float32               `json:"accuracy,omitempty"`
-  Results   *ValidateEmailResults  `json:"results,omitempty"`
+  Valid     *bool                       `json:"valid,omitempty"`
+  Accuracy  *float32                    `json:"accuracy,omitempty"`
+  Results   *ValidateEmailResults       `json:"results,omitempty"`
+  Extra     map[string]json.RawMessage  `json:"-"`
 }
 
 // ValidateEmailResults mapping
 type ValidateEmailResults struct {
-  Gravatar     *bool  `json:"gravatar,omitempty"`
-  Gibberish    *bool  `json:"gibberish,omitempty"`
-  Disposable   *bool  `json:"disposable,omitempty"`
-  Webmail      *bool  `json:"webmail,omitempty"`
-  MXRecords    *bool  `json:"mx_records,omitempty"`
-  SMTPServer   *bool  `json:"smtp_server,omitempty"`
-  SMTPCheck    *bool  `json:"smtp_check,omitempty"`
-  SPFPolicy    *bool  `json:"spf_policy,omitempty"`
-  DMARCPolicy  *bool  `json:"dmarc_policy,omitempty"`
-  CatchAll     *bool  `json:"catch_all,omitempty"`
-  HighVolume   *bool  `json:"high_volume,omitempty"`
+  Gravatar     *bool                       `json:"gravatar,omitempty"`
+  Gibberish    *bool                       `json:"gibberish,omitempty"`
+  Disposable   *bool                       `json:"disposable,omitempty"`
+  Webmail      *bool                       `json:"webmail,omitempty"`
+  MXRecords    *bool                       `json:"mx_records,omitempty"`
+  SMTPServer   *bool                       `json:"smtp_server,omitempty"`
+  SMTPCheck    *bool                       `json:"smtp_check,omitempty"`
+  SPFPolicy    *bool                       `json:"spf_policy,omitempty"`
+  DMARCPolicy  *bool                       `json:"dmarc_policy,omitempty"`
+  CatchAll     *bool                       `json:"catch_all,omitempty"`
+  HighVolume   *bool                       `json:"high_volume,omitempty"`
+  Extra        map[string]json.RawMessage  `json:"-"`
 }
 
 
@@ -50,10 +53,38 @@ func (instance ValidateEmailResults) String() string {
 }
 
 
+// UnmarshalJSON decodes ValidateEmailData and keeps unknown fields in Extra
+func (instance *ValidateEmailData) UnmarshalJSON(data []byte) error {
+  type mapping ValidateEmailData
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes ValidateEmailData along with unknown fields from Extra
+func (instance ValidateEmailData) MarshalJSON() ([]byte, error) {
+  type mapping ValidateEmailData
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+// UnmarshalJSON decodes ValidateEmailResults and keeps unknown fields in Extra
+func (instance *ValidateEmailResults) UnmarshalJSON(data []byte) error {
+  type mapping ValidateEmailResults
+  return unmarshalWithExtra(data, (*mapping)(instance), &instance.Extra)
+}
+
+// MarshalJSON encodes ValidateEmailResults along with unknown fields from Extra
+func (instance ValidateEmailResults) MarshalJSON() ([]byte, error) {
+  type mapping ValidateEmailResults
+  return marshalWithExtra(mapping(instance), instance.Extra)
+}
+
+
 // ValidateEmail verifies if an email is valid and if it exists.
 func (service *VerifyService) ValidateEmail(email string) (*ValidateEmailData, *Response, error) {
   url := fmt.Sprintf("verify/validate/email?email=%s", url.QueryEscape(email))
-  req, _ := service.client.NewRequest("GET", url, nil)
+  req, err := service.client.NewRequest("GET", url, nil)
+  if err != nil {
+    return nil, nil, err
+  }
 
   data := new(ValidateEmailData)
   resp, err := service.client.Do(req, data)
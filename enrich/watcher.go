@@ -0,0 +1,749 @@
+// Copyright 2017 Valerian Saliou. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enrich
+
+
+import (
+  "encoding/json"
+  "fmt"
+  "sort"
+  "strings"
+  "sync"
+  "time"
+)
+
+
+const (
+  defaultWatchPersonInterval = 30 * 24 * time.Hour
+  defaultWatchCompanyInterval = 30 * 24 * time.Hour
+  defaultWatchNetworkInterval = 7 * 24 * time.Hour
+  defaultWatchBudgetPeriod = 24 * time.Hour
+  defaultWatchTickInterval = time.Minute
+  defaultWatchRetryBackoff = 5 * time.Minute
+  defaultWatchCurrency = "USD"
+)
+
+
+// WatchKind maps the kind of a watched record
+type WatchKind string
+
+// WatchEventKind maps the kind of a change event
+type WatchEventKind string
+
+// WatchCallback is called when a watched record changes
+type WatchCallback func(event WatchEvent)
+
+// WatchErrorCallback is called when a watched record could not be re-enriched
+type WatchErrorCallback func(target WatchTarget, err error)
+
+// WatchTarget maps a watched record, by its lookup (eg. person by 'email', company by 'domain', network by 'ip')
+type WatchTarget struct {
+  Kind   WatchKind  `json:"kind"`
+  Key    string     `json:"key"`
+  Value  string     `json:"value"`
+}
+
+// WatchState maps the stored state of a watched record
+//
+// Records that could not be re-enriched (or whose discovery is pending) are retried at RetryAt, with an exponential backoff on consecutive failures.
+type WatchState struct {
+  Target     WatchTarget      `json:"target"`
+  Data       json.RawMessage  `json:"data,omitempty"`
+  CheckedAt  time.Time        `json:"checked_at"`
+  ChangedAt  time.Time        `json:"changed_at"`
+  RetryAt    time.Time        `json:"retry_at"`
+  Failures   int              `json:"failures"`
+}
+
+// WatchEvent maps a change of a watched record
+//
+// Previous and current data are *EnrichPersonData, *EnrichCompanyData or *EnrichNetworkData, depending on the kind.
+type WatchEvent struct {
+  Kind      WatchEventKind
+  Target    WatchTarget
+  Changes   Changes
+  Previous  interface{}
+  Current   interface{}
+}
+
+// WatchStore persists the state of watched records
+type WatchStore interface {
+  List() ([]*WatchState, error)
+  Get(id string) (*WatchState, error)
+  Put(state *WatchState) error
+  Delete(id string) error
+}
+
+// MemoryWatchStore stores the state of watched records in memory
+type MemoryWatchStore struct {
+  lock    sync.RWMutex
+  states  map[string]*WatchState
+}
+
+// Watcher re-enriches watched records on a schedule, within a lookup budget, and fires callbacks on changes
+type Watcher struct {
+  Intervals     map[WatchKind]time.Duration
+  Budget        int
+  BudgetPeriod  time.Duration
+  QuotaReserve  int64
+  TickInterval  time.Duration
+  RetryBackoff  time.Duration
+  Currency      string
+  Rates         CurrencyRates
+
+  client          *Client
+  store           WatchStore
+  lock            sync.Mutex
+  checkLock       sync.Mutex
+  stateLock       sync.Mutex
+  callbacks       map[WatchEventKind][]WatchCallback
+  errorCallbacks  []WatchErrorCallback
+  spent           int
+  periodStart     time.Time
+  stop            chan struct{}
+  done            chan struct{}
+}
+
+
+// Watch kinds
+const (
+  WatchPerson WatchKind = "person"
+  WatchCompany WatchKind = "company"
+  WatchNetwork WatchKind = "network"
+)
+
+// Watch event kinds
+const (
+  WatchEventChanged WatchEventKind = "changed"
+  WatchEventEmployerChanged WatchEventKind = "employer_changed"
+  WatchEventTitleChanged WatchEventKind = "title_changed"
+  WatchEventLocationChanged WatchEventKind = "location_changed"
+  WatchEventHeadcountChanged WatchEventKind = "headcount_bucket_changed"
+  WatchEventRevenueChanged WatchEventKind = "revenue_bucket_changed"
+  WatchEventCompanyChanged WatchEventKind = "company_changed"
+)
+
+
+// String returns the string representation of WatchTarget
+func (instance WatchTarget) String() string {
+  return instance.ID()
+}
+
+// String returns the string representation of WatchEvent
+func (instance WatchEvent) String() string {
+  return Stringify(instance)
+}
+
+
+// ID returns the unique identifier of the target (eg. 'person:email:john@acme.com')
+func (instance WatchTarget) ID() string {
+  return string(instance.Kind) + ":" + instance.Key + ":" + instance.Value
+}
+
+
+// NewMemoryWatchStore returns an in-memory watch store
+func NewMemoryWatchStore() *MemoryWatchStore {
+  return &MemoryWatchStore{states: make(map[string]*WatchState)}
+}
+
+
+// List returns all stored states
+func (store *MemoryWatchStore) List() ([]*WatchState, error) {
+  store.lock.RLock()
+  defer store.lock.RUnlock()
+
+  states := make([]*WatchState, 0, len(store.states))
+
+  for _, state := range store.states {
+    stored := *state
+    states = append(states, &stored)
+  }
+
+  return states, nil
+}
+
+
+// Get returns a stored state, or nil if unknown
+func (store *MemoryWatchStore) Get(id string) (*WatchState, error) {
+  store.lock.RLock()
+  defer store.lock.RUnlock()
+
+  if state, ok := store.states[id]; ok == true {
+    stored := *state
+
+    return &stored, nil
+  }
+
+  return nil, nil
+}
+
+
+// Put stores a state
+func (store *MemoryWatchStore) Put(state *WatchState) error {
+  store.lock.Lock()
+  defer store.lock.Unlock()
+
+  stored := *state
+  store.states[state.Target.ID()] = &stored
+
+  return nil
+}
+
+
+// Delete removes a stored state
+func (store *MemoryWatchStore) Delete(id string) error {
+  store.lock.Lock()
+  defer store.lock.Unlock()
+
+  delete(store.states, id)
+
+  return nil
+}
+
+
+// NewWatcher returns a watcher using a client, persisting states in a store (in memory if nil)
+func NewWatcher(client *Client, store WatchStore) *Watcher {
+  if store == nil {
+    store = NewMemoryWatchStore()
+  }
+
+  return &Watcher{
+    Intervals: map[WatchKind]time.Duration{
+      WatchPerson: defaultWatchPersonInterval,
+      WatchCompany: defaultWatchCompanyInterval,
+      WatchNetwork: defaultWatchNetworkInterval,
+    },
+    BudgetPeriod: defaultWatchBudgetPeriod,
+    TickInterval: defaultWatchTickInterval,
+    RetryBackoff: defaultWatchRetryBackoff,
+    Currency: defaultWatchCurrency,
+    client: client,
+    store: store,
+    callbacks: make(map[WatchEventKind][]WatchCallback),
+  }
+}
+
+
+// Watch starts watching a record (its first check stores a baseline, without firing callbacks)
+func (watcher *Watcher) Watch(target WatchTarget) error {
+  switch target.Kind {
+    case WatchPerson, WatchCompany, WatchNetwork:
+      break
+
+    default:
+      return fmt.Errorf("unknown watch kind: %q", target.Kind)
+  }
+
+  watcher.stateLock.Lock()
+  defer watcher.stateLock.Unlock()
+
+  state, err := watcher.store.Get(target.ID())
+  if err != nil || state != nil {
+    return err
+  }
+
+  return watcher.store.Put(&WatchState{Target: target})
+}
+
+
+// Unwatch stops watching a record (a check in progress does not store it back)
+func (watcher *Watcher) Unwatch(target WatchTarget) error {
+  watcher.stateLock.Lock()
+  defer watcher.stateLock.Unlock()
+
+  return watcher.store.Delete(target.ID())
+}
+
+
+// On registers a callback for an event kind
+func (watcher *Watcher) On(kind WatchEventKind, callback WatchCallback) {
+  watcher.lock.Lock()
+  defer watcher.lock.Unlock()
+
+  watcher.callbacks[kind] = append(watcher.callbacks[kind], callback)
+}
+
+
+// OnError registers a callback for re-enrichment errors
+func (watcher *Watcher) OnError(callback WatchErrorCallback) {
+  watcher.lock.Lock()
+  defer watcher.lock.Unlock()
+
+  watcher.errorCallbacks = append(watcher.errorCallbacks, callback)
+}
+
+
+// Check re-enriches all due records (least recently checked first), returning the number of lookups performed
+//
+// The check stops when the budget is spent, or when the API quota falls to the reserve.
+func (watcher *Watcher) Check() (int, error) {
+  watcher.checkLock.Lock()
+  defer watcher.checkLock.Unlock()
+
+  states, err := watcher.store.List()
+  if err != nil {
+    return 0, err
+  }
+
+  now := time.Now()
+
+  var due []*WatchState
+
+  for _, state := range states {
+    if watcher.isDue(state, now) == true {
+      due = append(due, state)
+    }
+  }
+
+  sort.SliceStable(due, func(i, j int) bool {
+    return due[i].CheckedAt.Before(due[j].CheckedAt)
+  })
+
+  lookups := 0
+
+  for _, state := range due {
+    if watcher.spendBudget(now) == false {
+      break
+    }
+
+    lookups++
+
+    current, response, err := watcher.enrich(state.Target)
+
+    if err != nil {
+      // Rate-limited or out of quota, thus further lookups would fail as well
+      if responseErr, ok := err.(*ResponseError); ok == true && (responseErr.Reason == "rate_limited" || responseErr.Reason == "quota_exceeded") {
+        return lookups, err
+      }
+
+      // Other failures (eg. server errors or timeouts) are retried before the next interval
+      state.Failures++
+      state.RetryAt = now.Add(watcher.retryBackoff(state))
+
+      watched, putErr := watcher.put(state)
+      if putErr != nil {
+        return lookups, putErr
+      }
+
+      if watched == true {
+        watcher.fireError(state.Target, err)
+      }
+
+      continue
+    }
+
+    // Pending discoveries return empty data, which must not replace the baseline
+    if response != nil && response.Meta != nil && response.Meta.Discovery == DiscoveryStatusCreated {
+      state.Failures = 0
+      state.RetryAt = now.Add(watcher.retryBackoff(state))
+
+      if _, err = watcher.put(state); err != nil {
+        return lookups, err
+      }
+    } else if err = watcher.update(state, current, now); err != nil {
+      return lookups, err
+    }
+
+    if response != nil && response.Meta != nil && response.Meta.Quota != nil && response.Meta.Quota.Remaining <= watcher.QuotaReserve {
+      break
+    }
+  }
+
+  return lookups, nil
+}
+
+
+// Start checks due records periodically, in the background
+func (watcher *Watcher) Start() {
+  watcher.lock.Lock()
+  defer watcher.lock.Unlock()
+
+  if watcher.stop != nil {
+    return
+  }
+
+  watcher.stop = make(chan struct{})
+  watcher.done = make(chan struct{})
+
+  go watcher.run(watcher.stop, watcher.done)
+}
+
+
+// Stop stops periodic checks, waiting for the current check to complete
+func (watcher *Watcher) Stop() {
+  watcher.lock.Lock()
+
+  stop, done := watcher.stop, watcher.done
+  watcher.stop, watcher.done = nil, nil
+
+  watcher.lock.Unlock()
+
+  if stop != nil {
+    close(stop)
+    <-done
+  }
+}
+
+
+// run checks due records on each tick, until stopped
+func (watcher *Watcher) run(stop chan struct{}, done chan struct{}) {
+  defer close(done)
+
+  interval := watcher.TickInterval
+
+  if interval <= 0 {
+    interval = defaultWatchTickInterval
+  }
+
+  ticker := time.NewTicker(interval)
+  defer ticker.Stop()
+
+  for {
+    if _, err := watcher.Check(); err != nil {
+      watcher.fireError(WatchTarget{}, err)
+    }
+
+    select {
+      case <-stop:
+        return
+
+      case <-ticker.C:
+        continue
+    }
+  }
+}
+
+
+// update diffs the current data against the stored data, stores it and fires events
+func (watcher *Watcher) update(state *WatchState, current interface{}, now time.Time) error {
+  var events []WatchEvent
+
+  if len(state.Data) > 0 {
+    previous := newWatchData(state.Target.Kind)
+
+    if err := json.Unmarshal(state.Data, previous); err != nil {
+      return err
+    }
+
+    changes, err := Diff(previous, current)
+    if err != nil {
+      return err
+    }
+
+    if len(changes) > 0 {
+      events = watcher.detect(state.Target, previous, current, changes)
+      state.ChangedAt = now
+    }
+  }
+
+  data, err := json.Marshal(current)
+  if err != nil {
+    return err
+  }
+
+  state.Data = data
+  state.CheckedAt = now
+  state.RetryAt = time.Time{}
+  state.Failures = 0
+
+  watched, err := watcher.put(state)
+  if err != nil || watched == false {
+    return err
+  }
+
+  for _, event := range events {
+    watcher.fire(event)
+  }
+
+  return nil
+}
+
+
+// detect returns the events for changes (a generic change event, then meaningful ones)
+func (watcher *Watcher) detect(target WatchTarget, previous interface{}, current interface{}, changes Changes) []WatchEvent {
+  events := []WatchEvent{{Kind: WatchEventChanged, Changes: changes}}
+
+  switch previousData := previous.(type) {
+    case *EnrichPersonData:
+      currentData := current.(*EnrichPersonData)
+
+      previousEmployment, currentEmployment := watchEmployment(previousData.Person), watchEmployment(currentData.Person)
+
+      if watchEmployerKey(previousEmployment) != watchEmployerKey(currentEmployment) {
+        events = append(events, WatchEvent{Kind: WatchEventEmployerChanged, Changes: changes.Filter("person.employments", "companies")})
+      } else if previousEmployment != nil && currentEmployment != nil && stringValue(previousEmployment.Title) != stringValue(currentEmployment.Title) {
+        events = append(events, WatchEvent{Kind: WatchEventTitleChanged, Changes: changes.Filter("person.employments")})
+      }
+
+      if location := changes.Filter("person.address.city", "person.address.region", "person.address.country"); len(location) > 0 {
+        events = append(events, WatchEvent{Kind: WatchEventLocationChanged, Changes: location})
+      }
+
+    case *EnrichCompanyData:
+      currentData := current.(*EnrichCompanyData)
+
+      if watchHeadcountBucket(previousData.Company) != watchHeadcountBucket(currentData.Company) {
+        events = append(events, WatchEvent{Kind: WatchEventHeadcountChanged, Changes: changes.Filter("company.metrics.employees")})
+      }
+      if watcher.revenueBucket(previousData.Company) != watcher.revenueBucket(currentData.Company) {
+        events = append(events, WatchEvent{Kind: WatchEventRevenueChanged, Changes: changes.Filter("company.metrics.annual_revenue")})
+      }
+
+      if location := changes.Filter("company.address.city", "company.address.region", "company.address.country"); len(location) > 0 {
+        events = append(events, WatchEvent{Kind: WatchEventLocationChanged, Changes: location})
+      }
+
+    case *EnrichNetworkData:
+      currentData := current.(*EnrichNetworkData)
+
+      if watchCompanyKey(previousData.Company) != watchCompanyKey(currentData.Company) {
+        events = append(events, WatchEvent{Kind: WatchEventCompanyChanged, Changes: changes.Filter("company")})
+      }
+
+      if location := changes.Filter("network.geolocation.city", "network.geolocation.region", "network.geolocation.country"); len(location) > 0 {
+        events = append(events, WatchEvent{Kind: WatchEventLocationChanged, Changes: location})
+      }
+  }
+
+  for i := range events {
+    events[i].Target, events[i].Previous, events[i].Current = target, previous, current
+  }
+
+  return events
+}
+
+
+// enrich re-enriches a target
+func (watcher *Watcher) enrich(target WatchTarget) (interface{}, *Response, error) {
+  switch target.Kind {
+    case WatchPerson:
+      data, response, err := watcher.client.Enrich.EnrichPersonBy(target.Key, target.Value)
+      if err != nil {
+        return nil, response, err
+      }
+
+      return data, response, nil
+
+    case WatchCompany:
+      data, response, err := watcher.client.Enrich.EnrichCompanyBy(target.Key, target.Value)
+      if err != nil {
+        return nil, response, err
+      }
+
+      return data, response, nil
+
+    case WatchNetwork:
+      data, response, err := watcher.client.Enrich.EnrichNetworkBy(target.Key, target.Value)
+      if err != nil {
+        return nil, response, err
+      }
+
+      return data, response, nil
+  }
+
+  return nil, nil, fmt.Errorf("unknown watch kind: %q", target.Kind)
+}
+
+
+// put stores the state of a record, unless it was unwatched in the meantime (returning whether it is still watched)
+func (watcher *Watcher) put(state *WatchState) (bool, error) {
+  watcher.stateLock.Lock()
+  defer watcher.stateLock.Unlock()
+
+  stored, err := watcher.store.Get(state.Target.ID())
+  if err != nil || stored == nil {
+    return false, err
+  }
+
+  return true, watcher.store.Put(state)
+}
+
+
+// isDue returns whether a record is due for re-enrichment
+func (watcher *Watcher) isDue(state *WatchState, now time.Time) bool {
+  if state.RetryAt.IsZero() == false {
+    return now.Before(state.RetryAt) == false
+  }
+
+  return state.CheckedAt.IsZero() == true || now.Sub(state.CheckedAt) >= watcher.interval(state.Target.Kind)
+}
+
+
+// retryBackoff returns the delay before retrying a record, doubling on each consecutive failure (up to its interval)
+func (watcher *Watcher) retryBackoff(state *WatchState) time.Duration {
+  backoff := watcher.RetryBackoff
+
+  if backoff <= 0 {
+    backoff = defaultWatchRetryBackoff
+  }
+
+  interval := watcher.interval(state.Target.Kind)
+
+  for i := 1; i < state.Failures && backoff < interval; i++ {
+    backoff *= 2
+  }
+
+  if backoff > interval {
+    return interval
+  }
+
+  return backoff
+}
+
+
+// spendBudget spends a lookup from the budget of the current period, if any left
+func (watcher *Watcher) spendBudget(now time.Time) bool {
+  watcher.lock.Lock()
+  defer watcher.lock.Unlock()
+
+  if watcher.Budget <= 0 {
+    return true
+  }
+
+  if watcher.periodStart.IsZero() == true || now.Sub(watcher.periodStart) >= watcher.BudgetPeriod {
+    watcher.periodStart = now
+    watcher.spent = 0
+  }
+
+  if watcher.spent >= watcher.Budget {
+    return false
+  }
+
+  watcher.spent++
+
+  return true
+}
+
+
+// interval returns the re-enrichment interval of a kind
+func (watcher *Watcher) interval(kind WatchKind) time.Duration {
+  if interval, ok := watcher.Intervals[kind]; ok == true && interval > 0 {
+    return interval
+  }
+
+  switch kind {
+    case WatchCompany:
+      return defaultWatchCompanyInterval
+
+    case WatchNetwork:
+      return defaultWatchNetworkInterval
+  }
+
+  return defaultWatchPersonInterval
+}
+
+
+// revenueBucket returns the revenue bucket label of a company, if known
+func (watcher *Watcher) revenueBucket(company *Company) string {
+  if company == nil || company.Metrics == nil || company.Metrics.AnnualRevenue == nil {
+    return ""
+  }
+
+  bucket, err := company.Metrics.AnnualRevenue.Bucket(watcher.Currency, watcher.Rates)
+  if err != nil {
+    return ""
+  }
+
+  return bucket.Label
+}
+
+
+// fire calls the callbacks registered for an event
+func (watcher *Watcher) fire(event WatchEvent) {
+  watcher.lock.Lock()
+  callbacks := watcher.callbacks[event.Kind]
+  watcher.lock.Unlock()
+
+  for _, callback := range callbacks {
+    callback(event)
+  }
+}
+
+
+// fireError calls the error callbacks
+func (watcher *Watcher) fireError(target WatchTarget, err error) {
+  watcher.lock.Lock()
+  callbacks := watcher.errorCallbacks
+  watcher.lock.Unlock()
+
+  for _, callback := range callbacks {
+    callback(target, err)
+  }
+}
+
+
+// newWatchData returns empty data for a kind
+func newWatchData(kind WatchKind) interface{} {
+  switch kind {
+    case WatchCompany:
+      return new(EnrichCompanyData)
+
+    case WatchNetwork:
+      return new(EnrichNetworkData)
+  }
+
+  return new(EnrichPersonData)
+}
+
+
+// watchEmployment returns the current employment of a person (ie. the first one)
+func watchEmployment(person *Person) *PersonEmployment {
+  if person == nil || person.Employments == nil || len(*person.Employments) == 0 {
+    return nil
+  }
+
+  return &(*person.Employments)[0]
+}
+
+
+// watchEmployerKey returns a key identifying an employer (by ID, domain or name)
+func watchEmployerKey(employment *PersonEmployment) string {
+  switch {
+    case employment == nil:
+      return ""
+
+    case employment.ID != nil && *employment.ID != "":
+      return "id:" + *employment.ID
+
+    case employment.Domain != nil && *employment.Domain != "":
+      return "domain:" + strings.ToLower(*employment.Domain)
+  }
+
+  return "name:" + strings.ToLower(strings.TrimSpace(stringValue(employment.Name)))
+}
+
+
+// watchCompanyKey returns a key identifying a company (by ID, domain or name)
+func watchCompanyKey(company *Company) string {
+  switch {
+    case company == nil:
+      return ""
+
+    case company.ID != nil && *company.ID != "":
+      return "id:" + *company.ID
+
+    case company.Contact != nil && company.Contact.Domain != nil && *company.Contact.Domain != "":
+      return "domain:" + strings.ToLower(*company.Contact.Domain)
+  }
+
+  return "name:" + strings.ToLower(strings.TrimSpace(stringValue(company.Name)))
+}
+
+
+// watchHeadcountBucket returns the headcount bucket label of a company, if known
+func watchHeadcountBucket(company *Company) string {
+  if company == nil || company.Metrics == nil {
+    return ""
+  }
+
+  if employees, ok := company.Metrics.EmployeeRange(); ok == true {
+    if bucket, ok := employees.Bucket(); ok == true {
+      return bucket.Label
+    }
+  }
+
+  return ""
+}